@@ -0,0 +1,245 @@
+// Package bench runs solvers over a corpus of instance files and reports
+// their performance, so regressions are visible across releases.
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/Spi1y/tsp-solver/gen"
+	"github.com/Spi1y/tsp-solver/little"
+	"github.com/Spi1y/tsp-solver/solver"
+	"github.com/Spi1y/tsp-solver/solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver/tasks"
+	"github.com/Spi1y/tsp-solver/solver2"
+	"github.com/Spi1y/tsp-solver/solver2/stats"
+	"github.com/Spi1y/tsp-solver/solver2/tail"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/Spi1y/tsp-solver/solver3"
+)
+
+// Result is a solution reported by an engine
+type Result struct {
+	Distance   types.Distance
+	LowerBound types.Distance
+	Stats      stats.Stats
+}
+
+// Engine is a solver with a fixed configuration
+type Engine struct {
+	Name string
+	// Solve solves the instance. The search should stop when the context
+	// is done, returning the best solution found so far with the context error
+	Solve func(ctx context.Context, m [][]types.Distance) (Result, error)
+}
+
+// Engines returns all known engine configurations
+func Engines() []Engine {
+	return []Engine{
+		{"solver/heap", solveSolver(tasks.QueueHeap)},
+		{"solver2/heap", solveSolver2(0)},
+		{"solver2/hybrid", solveSolver2(3)},
+		{"solver2/auto", solveSolver2(tail.ThresholdAuto)},
+		{"solver3/hybrid", solveSolver3(3)},
+		{"solver3/auto", solveSolver3(tail.ThresholdAuto)},
+		{"little", solveLittle},
+	}
+}
+
+// FindEngines returns engines with the given names
+func FindEngines(names []string) ([]Engine, error) {
+	all := Engines()
+	result := make([]Engine, 0, len(names))
+
+	for _, name := range names {
+		found := false
+		for _, e := range all {
+			if e.Name == name {
+				result = append(result, e)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Unknown engine %q", name)
+		}
+	}
+
+	return result, nil
+}
+
+func solveSolver(q tasks.QueueType) func(context.Context, [][]types.Distance) (Result, error) {
+	return func(ctx context.Context, m [][]types.Distance) (Result, error) {
+		slice := make([][]int, len(m))
+		for i := range m {
+			slice[i] = make([]int, len(m))
+			for j := range m[i] {
+				slice[i][j] = int(m[i][j])
+			}
+			slice[i][i] = -1
+		}
+
+		s := &solver.Solver{DistanceMatrix: matrix.ConvertToMatrix(slice)}
+		_, dist, err := s.SolveContext(ctx, q)
+		if err != nil {
+			// Interrupted search has no lower bound
+			return Result{Distance: types.Distance(dist)}, err
+		}
+
+		return Result{Distance: types.Distance(dist), LowerBound: types.Distance(dist)}, nil
+	}
+}
+
+func solveSolver2(threshold types.Index) func(context.Context, [][]types.Distance) (Result, error) {
+	return func(ctx context.Context, m [][]types.Distance) (Result, error) {
+		s := &solver2.Solver{RecursiveThreshold: threshold}
+		_, dist, st, err := s.SolveContext(ctx, m)
+
+		return Result{Distance: dist, LowerBound: st.LowerBound, Stats: st}, err
+	}
+}
+
+func solveSolver3(threshold types.Index) func(context.Context, [][]types.Distance) (Result, error) {
+	return func(ctx context.Context, m [][]types.Distance) (Result, error) {
+		s := &solver3.Solver{RecursiveThreshold: threshold}
+		_, dist, st, err := s.SolveContext(ctx, m)
+
+		return Result{Distance: dist, LowerBound: st.LowerBound, Stats: st}, err
+	}
+}
+
+func solveLittle(ctx context.Context, m [][]types.Distance) (Result, error) {
+	s := &little.Solver{}
+	_, dist, st, err := s.SolveContext(ctx, m)
+
+	return Result{Distance: dist, LowerBound: st.LowerBound, Stats: st}, err
+}
+
+// Record is a result of a single engine run on a single instance
+type Record struct {
+	Instance string `json:"instance"`
+	Size     int    `json:"size"`
+	Engine   string `json:"engine"`
+	// Wall time of the run, in nanoseconds in JSON
+	Duration time.Duration `json:"duration"`
+	// Search was stopped by the time limit
+	TimedOut      bool `json:"timedOut"`
+	TasksExpanded int  `json:"tasksExpanded"`
+	// Memory allocated during the run
+	AllocBytes uint64 `json:"allocBytes"`
+	Allocs     uint64 `json:"allocs"`
+
+	Distance   types.Distance `json:"distance"`
+	LowerBound types.Distance `json:"lowerBound"`
+	// Known optimum of the instance, zero if it is unknown
+	Optimum types.Distance `json:"optimum,omitempty"`
+	// Relative excess of the distance over the known optimum
+	OptimumGap float64 `json:"optimumGap"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Runner runs engines over instances
+type Runner struct {
+	Engines []Engine
+	// Time limit of a single run, zero means no limit
+	TimeLimit time.Duration
+}
+
+// Run runs every engine on every instance sequentially
+func (r *Runner) Run(ctx context.Context, instances []*gen.Instance) ([]Record, error) {
+	records := make([]Record, 0, len(instances)*len(r.Engines))
+
+	for _, inst := range instances {
+		for _, e := range r.Engines {
+			if err := ctx.Err(); err != nil {
+				return records, err
+			}
+
+			records = append(records, r.runSingle(ctx, inst, e))
+		}
+	}
+
+	return records, nil
+}
+
+func (r *Runner) runSingle(ctx context.Context, inst *gen.Instance, e Engine) Record {
+	rec := Record{
+		Instance: inst.Name,
+		Size:     len(inst.Matrix),
+		Engine:   e.Name,
+		Optimum:  inst.Optimum,
+	}
+
+	if r.TimeLimit != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.TimeLimit)
+		defer cancel()
+	}
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+	res, err := e.Solve(ctx, inst.Matrix)
+	rec.Duration = time.Since(start)
+
+	runtime.ReadMemStats(&after)
+	rec.AllocBytes = after.TotalAlloc - before.TotalAlloc
+	rec.Allocs = after.Mallocs - before.Mallocs
+
+	rec.Distance = res.Distance
+	rec.LowerBound = res.LowerBound
+	rec.TasksExpanded = res.Stats.TasksExpanded
+	if (inst.Optimum != 0) && (res.Distance != 0) {
+		rec.OptimumGap = (float64(res.Distance) - float64(inst.Optimum)) / float64(inst.Optimum)
+	}
+
+	switch {
+	case err == nil:
+	case errors.Is(err, context.DeadlineExceeded):
+		rec.TimedOut = true
+	default:
+		rec.Error = err.Error()
+	}
+
+	return rec
+}
+
+// LoadCorpus loads all instance files (JSON and TSPLIB) from the directory
+// in the order of file names
+func LoadCorpus(dir string) ([]*gen.Instance, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch filepath.Ext(entry.Name()) {
+		case ".json", ".tsp", ".atsp":
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	instances := make([]*gen.Instance, 0, len(names))
+	for _, name := range names {
+		inst, err := gen.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, inst)
+	}
+
+	return instances, nil
+}
@@ -0,0 +1,116 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Spi1y/tsp-solver/gen"
+	"github.com/Spi1y/tsp-solver/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunner_Run(t *testing.T) {
+	dir := t.TempDir()
+	for i, kind := range []gen.Kind{gen.Euclidean, gen.Asymmetric} {
+		inst, err := gen.NewInstance(gen.Options{Kind: kind, Size: 8, Seed: int64(i)})
+		assert.NoError(t, err)
+		inst.Optimum = verify.Optimum(inst.Matrix)
+
+		name := filepath.Join(dir, inst.Name+".json")
+		if i == 1 {
+			name = filepath.Join(dir, inst.Name+".atsp")
+		}
+		assert.NoError(t, inst.WriteFile(name))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("Not an instance"), 0o644))
+
+	instances, err := LoadCorpus(dir)
+	assert.NoError(t, err)
+	assert.Len(t, instances, 2)
+
+	// Optimum is not stored in TSPLIB files
+	for _, inst := range instances {
+		if strings.HasPrefix(inst.Name, "asymmetric") {
+			assert.Zero(t, inst.Optimum)
+			inst.Optimum = verify.Optimum(inst.Matrix)
+		}
+	}
+
+	r := &Runner{Engines: Engines(), TimeLimit: time.Minute}
+	records, err := r.Run(context.Background(), instances)
+	assert.NoError(t, err)
+	assert.Len(t, records, len(instances)*len(r.Engines))
+
+	for _, rec := range records {
+		assert.Empty(t, rec.Error)
+		assert.False(t, rec.TimedOut)
+		assert.Equal(t, rec.Optimum, rec.Distance, rec.Engine)
+		assert.Equal(t, rec.Distance, rec.LowerBound, rec.Engine)
+		assert.Zero(t, rec.OptimumGap)
+		assert.NotZero(t, rec.Duration)
+		if rec.Engine != "solver/heap" {
+			assert.NotZero(t, rec.TasksExpanded, rec.Engine)
+		}
+	}
+
+	_, err = LoadCorpus(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestRunner_RunTimeLimit(t *testing.T) {
+	inst, err := gen.NewInstance(gen.Options{Kind: gen.Clustered, Size: 40, Seed: 1})
+	assert.NoError(t, err)
+
+	engines, err := FindEngines([]string{"solver2/heap", "solver3/hybrid"})
+	assert.NoError(t, err)
+
+	r := &Runner{Engines: engines, TimeLimit: 10 * time.Millisecond}
+	records, err := r.Run(context.Background(), []*gen.Instance{inst})
+	assert.NoError(t, err)
+
+	for _, rec := range records {
+		assert.True(t, rec.TimedOut, rec.Engine)
+		assert.True(t, rec.Duration < time.Second)
+		assert.NotZero(t, rec.LowerBound)
+	}
+
+	// Solver without a lower bound is interrupted as well
+	engines, err = FindEngines([]string{"solver/heap"})
+	assert.NoError(t, err)
+
+	r.Engines = engines
+	records, err = r.Run(context.Background(), []*gen.Instance{inst})
+	assert.NoError(t, err)
+	assert.True(t, records[0].TimedOut)
+	assert.True(t, records[0].Duration < time.Second)
+
+	_, err = FindEngines([]string{"solver4"})
+	assert.Error(t, err)
+}
+
+func TestWriteReport(t *testing.T) {
+	records := []Record{
+		{Instance: "a", Size: 5, Engine: "solver2/auto", Duration: 1500 * time.Millisecond, Distance: 110, LowerBound: 100, Optimum: 100, OptimumGap: 0.1},
+		{Instance: "b", Size: 7, Engine: "solver3/auto", TimedOut: true, Error: "failed, badly"},
+	}
+
+	var b strings.Builder
+	assert.NoError(t, WriteCSV(&b, records))
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "instance,size,engine,duration,"))
+	assert.Equal(t, "a,5,solver2/auto,1.500000,false,0,0,0,110,100,100,0.100000,", lines[1])
+	assert.Equal(t, `b,7,solver3/auto,0.000000,true,0,0,0,0,0,0,0.000000,"failed, badly"`, lines[2])
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteJSON(&buf, records))
+	var restored []Record
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &restored))
+	assert.Equal(t, records, restored)
+}
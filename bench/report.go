@@ -0,0 +1,46 @@
+package bench
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// WriteJSON writes records as a JSON array
+func WriteJSON(w io.Writer, records []Record) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(records)
+}
+
+// WriteCSV writes records as CSV with a header. Durations are in seconds.
+func WriteCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"instance", "size", "engine", "duration", "timed_out", "tasks_expanded",
+		"alloc_bytes", "allocs", "distance", "lower_bound", "optimum", "optimum_gap", "error",
+	})
+
+	for _, rec := range records {
+		cw.Write([]string{
+			rec.Instance,
+			strconv.Itoa(rec.Size),
+			rec.Engine,
+			strconv.FormatFloat(rec.Duration.Seconds(), 'f', 6, 64),
+			strconv.FormatBool(rec.TimedOut),
+			strconv.Itoa(rec.TasksExpanded),
+			strconv.FormatUint(rec.AllocBytes, 10),
+			strconv.FormatUint(rec.Allocs, 10),
+			strconv.FormatUint(uint64(rec.Distance), 10),
+			strconv.FormatUint(uint64(rec.LowerBound), 10),
+			strconv.FormatUint(uint64(rec.Optimum), 10),
+			strconv.FormatFloat(rec.OptimumGap, 'f', 6, 64),
+			rec.Error,
+		})
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
@@ -0,0 +1,164 @@
+// Package cvrp solves the capacitated vehicle routing problem: vehicles of the
+// same capacity start at the depot (node 0), serve demands of other nodes and
+// return back. Nodes are clustered into routes with a heuristic construction,
+// and then each route is sequenced optimally with solver2. The result is not
+// guaranteed to be optimal, as clustering is heuristic.
+package cvrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Construction is a heuristic of clustering nodes into routes
+type Construction int
+
+const (
+	// Savings is the Clarke and Wright savings algorithm. It starts with a
+	// route per node and merges routes with the largest savings first.
+	Savings Construction = iota
+	// RouteFirst is the route-first cluster-second algorithm. It solves
+	// the TSP over all nodes and optimally splits the tour into routes.
+	RouteFirst
+)
+
+// Options of the solve
+type Options struct {
+	// Capacity of a vehicle
+	Capacity int
+	// Demands of nodes, the demand of the depot is ignored
+	Demands []int
+	// Maximum number of vehicles, zero means no limit
+	Vehicles     int
+	Construction Construction
+	// Optional configured solver for routes, a default one is used if nil
+	Solver *solver2.Solver
+}
+
+// Route is a tour of a single vehicle
+type Route struct {
+	// Path starts and ends with the depot
+	Path     []types.Index
+	Load     int
+	Distance types.Distance
+}
+
+// Solution is a set of routes
+type Solution struct {
+	Routes []Route
+	// Sum of route distances
+	Total types.Distance
+}
+
+// ErrNoSolution is returned when nodes can not be served with the given
+// number of vehicles
+var ErrNoSolution = errors.New("No routes serve all nodes")
+
+// Solve finds routes serving all nodes. If the search is interrupted with
+// the context, routes which are not sequenced yet are left in the order
+// of construction and returned along with the context error.
+func Solve(ctx context.Context, m *matrix.Matrix[types.Distance], o Options) (*Solution, error) {
+	err := validate(m, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var clusters [][]int
+	switch o.Construction {
+	case Savings:
+		clusters, err = savings(m, o)
+	case RouteFirst:
+		clusters, err = routeFirst(ctx, m, o)
+	default:
+		return nil, fmt.Errorf("Unknown construction %d", o.Construction)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if (o.Vehicles != 0) && (len(clusters) > o.Vehicles) {
+		return nil, ErrNoSolution
+	}
+
+	s := o.Solver
+	if s == nil {
+		s = &solver2.Solver{}
+	}
+
+	sol := &Solution{Routes: make([]Route, len(clusters))}
+	for i, cluster := range clusters {
+		route, err := sequence(ctx, s, m, cluster)
+		if (err != nil) && (ctx.Err() == nil) {
+			return nil, err
+		}
+
+		for _, node := range cluster {
+			route.Load += o.Demands[node]
+		}
+		sol.Routes[i] = route
+		sol.Total += route.Distance
+	}
+
+	return sol, ctx.Err()
+}
+
+// validate checks the options against the matrix
+func validate(m *matrix.Matrix[types.Distance], o Options) error {
+	if m.Size() == 0 {
+		return errors.New("Distance matrix is empty")
+	}
+	if len(o.Demands) != m.Size() {
+		return errors.New("Demands do not match the distance matrix")
+	}
+	if o.Capacity <= 0 {
+		return errors.New("Capacity must be positive")
+	}
+
+	for node := 1; node < m.Size(); node++ {
+		demand := o.Demands[node]
+		if (demand < 0) || (demand > o.Capacity) {
+			return fmt.Errorf("Demand %d of node %d does not fit the capacity", demand, node)
+		}
+	}
+
+	return nil
+}
+
+// sequence solves the TSP over the depot and the cluster nodes. The nodes
+// are left in the given order if the solve is interrupted before any tour
+// is found.
+func sequence(ctx context.Context, s *solver2.Solver, m *matrix.Matrix[types.Distance], cluster []int) (Route, error) {
+	nodes := append([]int{0}, cluster...)
+	sub, err := m.Sub(nodes)
+	if err != nil {
+		return Route{}, err
+	}
+
+	tour, _, _, err := s.SolveMatrix(ctx, sub)
+	if errors.Is(err, solver2.ErrNoTour) {
+		return Route{}, ErrNoSolution
+	}
+
+	route := Route{Path: make([]types.Index, 0, len(nodes)+1)}
+	if len(tour) == 0 {
+		for _, node := range nodes {
+			route.Path = append(route.Path, types.Index(node))
+		}
+		route.Path = append(route.Path, 0)
+	} else {
+		for _, node := range tour {
+			route.Path = append(route.Path, types.Index(nodes[node]))
+		}
+	}
+
+	for i := 1; i < len(route.Path); i++ {
+		route.Distance += m.At(int(route.Path[i-1]), int(route.Path[i]))
+	}
+
+	return route, err
+}
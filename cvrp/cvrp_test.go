@@ -0,0 +1,191 @@
+package cvrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/gen"
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolve(t *testing.T) {
+	for _, construction := range []Construction{Savings, RouteFirst} {
+		for _, kind := range []gen.Kind{gen.Euclidean, gen.Clustered, gen.Asymmetric} {
+			for _, size := range []int{1, 2, 5, 9} {
+				t.Run(fmt.Sprintf("%d/%v/%d", construction, kind, size), func(t *testing.T) {
+					m, demands := testInstance(t, kind, size)
+					o := Options{Capacity: 10, Demands: demands, Construction: construction}
+
+					sol, err := Solve(context.Background(), m, o)
+					assert.NoError(t, err)
+					assertSolution(t, m, o, sol)
+				})
+			}
+		}
+	}
+}
+
+func TestSolveSingleRoute(t *testing.T) {
+	m, demands := testInstance(t, gen.Asymmetric, 9)
+	s := &solver2.Solver{}
+	_, want, _, err := s.SolveMatrix(context.Background(), m)
+	assert.NoError(t, err)
+
+	// Everything fits one vehicle, so the route is the optimal TSP tour
+	for _, construction := range []Construction{Savings, RouteFirst} {
+		o := Options{Capacity: 1_000, Demands: demands, Construction: construction}
+		sol, err := Solve(context.Background(), m, o)
+		assert.NoError(t, err)
+		assertSolution(t, m, o, sol)
+		if assert.Len(t, sol.Routes, 1) {
+			assert.Equal(t, want, sol.Total)
+		}
+	}
+}
+
+func TestSolveVehicles(t *testing.T) {
+	// Depot edges are cheap, so every node prefers its own route
+	rows := make([][]types.Distance, 5)
+	for i := range rows {
+		rows[i] = make([]types.Distance, 5)
+		for j := range rows[i] {
+			switch {
+			case i == j:
+			case (i == 0) || (j == 0):
+				rows[i][j] = 1
+			default:
+				rows[i][j] = 100
+			}
+		}
+	}
+	m, err := matrix.FromRows(rows, 1_000_000)
+	assert.NoError(t, err)
+
+	for _, construction := range []Construction{Savings, RouteFirst} {
+		o := Options{Capacity: 2, Demands: []int{0, 1, 1, 1, 1}, Vehicles: 2, Construction: construction}
+		sol, err := Solve(context.Background(), m, o)
+		if assert.NoError(t, err, "%d", construction) {
+			assertSolution(t, m, o, sol)
+			assert.Len(t, sol.Routes, 2)
+		}
+
+		// Without the limit every node gets its own route
+		o.Vehicles = 0
+		sol, err = Solve(context.Background(), m, o)
+		if assert.NoError(t, err, "%d", construction) {
+			assert.Len(t, sol.Routes, 4)
+		}
+	}
+}
+
+func TestSolveErrors(t *testing.T) {
+	m, demands := testInstance(t, gen.Euclidean, 5)
+
+	_, err := Solve(context.Background(), m, Options{Capacity: 10, Demands: demands[:3]})
+	assert.Error(t, err)
+	_, err = Solve(context.Background(), m, Options{Capacity: 0, Demands: demands})
+	assert.Error(t, err)
+	_, err = Solve(context.Background(), m, Options{Capacity: 1, Demands: []int{0, 1, 2, 1, 1}})
+	assert.Error(t, err)
+	_, err = Solve(context.Background(), m, Options{Capacity: 10, Demands: demands, Construction: Construction(7)})
+	assert.Error(t, err)
+
+	// Each node needs its own vehicle
+	_, err = Solve(context.Background(), m, Options{Capacity: 1, Demands: []int{0, 1, 1, 1, 1}, Vehicles: 3})
+	assert.True(t, errors.Is(err, ErrNoSolution))
+}
+
+func TestSolveCanceled(t *testing.T) {
+	m, demands := testInstance(t, gen.Euclidean, 9)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Routes are left in the order of construction
+	o := Options{Capacity: 10, Demands: demands}
+	sol, err := Solve(ctx, m, o)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assertSolution(t, m, o, sol)
+}
+
+func TestSplit(t *testing.T) {
+	m, err := matrix.FromRows([][]types.Distance{
+		{0, 1, 10, 1},
+		{1, 0, 1, 10},
+		{10, 1, 0, 1},
+		{1, 10, 1, 0},
+	}, 1_000)
+	assert.NoError(t, err)
+	o := Options{Capacity: 2, Demands: []int{0, 1, 1, 1}}
+
+	// With capacity 2, three nodes need two routes
+	clusters, err := split(m, o, []int{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Len(t, clusters, 2)
+
+	// Route count is limited by vehicles
+	o.Vehicles = 1
+	_, err = split(m, o, []int{1, 2, 3})
+	assert.True(t, errors.Is(err, ErrNoSolution))
+
+	// Single route 0-1-2-3-0 is the shortest
+	o.Capacity = 3
+	clusters, err = split(m, o, []int{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int{{1, 2, 3}}, clusters)
+}
+
+func testInstance(t *testing.T, kind gen.Kind, size int) (*matrix.Matrix[types.Distance], []int) {
+	rows, err := gen.Generate(gen.Options{Kind: kind, Size: size, Seed: int64(size), MaxDistance: 1_000})
+	assert.NoError(t, err)
+	m, err := matrix.FromRows(rows, 1_000_000)
+	assert.NoError(t, err)
+
+	r := rand.New(rand.NewSource(int64(size)))
+	demands := make([]int, size)
+	for node := 1; node < size; node++ {
+		demands[node] = 1 + r.Intn(5)
+	}
+
+	return m, demands
+}
+
+// assertSolution checks that every node is served once, loads fit the
+// capacity and distances match the routes
+func assertSolution(t *testing.T, m *matrix.Matrix[types.Distance], o Options, sol *Solution) {
+	if !assert.NotNil(t, sol) {
+		return
+	}
+
+	seen := make([]int, m.Size())
+	var total types.Distance
+	for _, route := range sol.Routes {
+		assert.Equal(t, types.Index(0), route.Path[0])
+		assert.Equal(t, types.Index(0), route.Path[len(route.Path)-1])
+
+		load := 0
+		var distance types.Distance
+		for i := 1; i < len(route.Path); i++ {
+			distance += m.At(int(route.Path[i-1]), int(route.Path[i]))
+		}
+		for _, node := range route.Path[1 : len(route.Path)-1] {
+			seen[node]++
+			load += o.Demands[node]
+		}
+
+		assert.Equal(t, load, route.Load)
+		assert.LessOrEqual(t, route.Load, o.Capacity)
+		assert.Equal(t, distance, route.Distance)
+		total += distance
+	}
+	assert.Equal(t, total, sol.Total)
+
+	for node := 1; node < m.Size(); node++ {
+		assert.Equal(t, 1, seen[node], "node %d", node)
+	}
+}
@@ -0,0 +1,88 @@
+package cvrp
+
+import (
+	"sort"
+
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// saving is a decrease of the total distance if the route ending with node i
+// is followed by the route starting with node j
+type saving struct {
+	i, j  int
+	value int64
+}
+
+// savings clusters nodes with the Clarke and Wright algorithm. Routes are
+// kept as sequences, since the matrix may be asymmetric. Merges without
+// a positive saving are only made while there are more routes than vehicles.
+func savings(m *matrix.Matrix[types.Distance], o Options) ([][]int, error) {
+	size := m.Size()
+
+	// Routes of nodes, the route of a merged node is the one it was merged to
+	routeOf := make([]int, size)
+	routes := make([][]int, size)
+	loads := make([]int, size)
+	for node := 1; node < size; node++ {
+		if m.Forbidden(0, node) || m.Forbidden(node, 0) {
+			return nil, ErrNoSolution
+		}
+		routeOf[node] = node
+		routes[node] = []int{node}
+		loads[node] = o.Demands[node]
+	}
+
+	var list []saving
+	for i := 1; i < size; i++ {
+		for j := 1; j < size; j++ {
+			if (i == j) || m.Forbidden(i, j) {
+				continue
+			}
+
+			value := int64(m.At(i, 0)) + int64(m.At(0, j)) - int64(m.At(i, j))
+			list = append(list, saving{i, j, value})
+		}
+	}
+	sort.SliceStable(list, func(a, b int) bool {
+		return list[a].value > list[b].value
+	})
+
+	count := size - 1
+	for _, s := range list {
+		if (s.value <= 0) && ((o.Vehicles == 0) || (count <= o.Vehicles)) {
+			break
+		}
+
+		a, b := routeOf[s.i], routeOf[s.j]
+		if a == b {
+			continue
+		}
+
+		// The first route must end with i and the second one start with j
+		first, second := routes[a], routes[b]
+		if (first[len(first)-1] != s.i) || (second[0] != s.j) {
+			continue
+		}
+		if loads[a]+loads[b] > o.Capacity {
+			continue
+		}
+
+		routes[a] = append(first, second...)
+		loads[a] += loads[b]
+		for _, node := range second {
+			routeOf[node] = a
+		}
+		routes[b] = nil
+		count--
+	}
+
+	var clusters [][]int
+	for _, route := range routes {
+		if route != nil {
+			clusters = append(clusters, route)
+		}
+	}
+
+	return clusters, nil
+}
@@ -0,0 +1,115 @@
+package cvrp
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// routeFirst clusters nodes by splitting the optimal TSP tour over all nodes
+// into consecutive segments. If the solve is interrupted, the best tour found
+// so far is split. The split is optimal for the tour order: it is
+// the shortest path in the graph of segments which fit the capacity.
+func routeFirst(ctx context.Context, m *matrix.Matrix[types.Distance], o Options) ([][]int, error) {
+	s := o.Solver
+	if s == nil {
+		s = &solver2.Solver{}
+	}
+
+	tour, _, _, err := s.SolveMatrix(ctx, m)
+	if errors.Is(err, solver2.ErrNoTour) {
+		return nil, ErrNoSolution
+	}
+	if len(tour) == 0 {
+		return nil, err
+	}
+
+	// Nodes in the order of the tour without the depot
+	order := make([]int, 0, len(tour))
+	for _, node := range tour[1 : len(tour)-1] {
+		order = append(order, int(node))
+	}
+
+	return split(m, o, order)
+}
+
+// split splits the sequence of nodes into routes with the minimal total
+// distance. Routes are consecutive segments of the sequence, and there are
+// at most o.Vehicles of them if the number of vehicles is limited.
+func split(m *matrix.Matrix[types.Distance], o Options, order []int) ([][]int, error) {
+	n := len(order)
+	limit := n
+	if (o.Vehicles != 0) && (o.Vehicles < n) {
+		limit = o.Vehicles
+	}
+
+	// cost[r][k] is the minimal distance of r routes serving the first k
+	// nodes, and the last of these routes starts at prev[r][k]
+	cost := make([][]uint64, limit+1)
+	prev := make([][]int, limit+1)
+	for r := range cost {
+		cost[r] = make([]uint64, n+1)
+		prev[r] = make([]int, n+1)
+		for k := range cost[r] {
+			cost[r][k] = math.MaxUint64
+		}
+	}
+	cost[0][0] = 0
+
+	for r := 0; r < limit; r++ {
+		for start := 0; start < n; start++ {
+			if (cost[r][start] == math.MaxUint64) || m.Forbidden(0, order[start]) {
+				continue
+			}
+
+			load := 0
+			distance := uint64(m.At(0, order[start]))
+			for end := start; end < n; end++ {
+				node := order[end]
+				if end > start {
+					if m.Forbidden(order[end-1], node) {
+						break
+					}
+					distance += uint64(m.At(order[end-1], node))
+				}
+
+				load += o.Demands[node]
+				if load > o.Capacity {
+					break
+				}
+				if m.Forbidden(node, 0) {
+					continue
+				}
+
+				total := cost[r][start] + distance + uint64(m.At(node, 0))
+				if total < cost[r+1][end+1] {
+					cost[r+1][end+1] = total
+					prev[r+1][end+1] = start
+				}
+			}
+		}
+	}
+
+	routes := 0
+	for r := 1; r <= limit; r++ {
+		if cost[r][n] < cost[routes][n] {
+			routes = r
+		}
+	}
+	if cost[routes][n] == math.MaxUint64 {
+		return nil, ErrNoSolution
+	}
+
+	clusters := make([][]int, routes)
+	for k := n; routes > 0; routes-- {
+		start := prev[routes][k]
+		clusters[routes-1] = order[start:k]
+		k = start
+	}
+
+	return clusters, nil
+}
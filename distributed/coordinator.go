@@ -0,0 +1,353 @@
+package distributed
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Spi1y/tsp-solver/solver2"
+	"github.com/Spi1y/tsp-solver/solver2/tasks"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// DefaultSubtrees is the default number of subtrees the search is split into
+const DefaultSubtrees = 256
+
+// Time given to connected workers to ask for a job after the search is
+// finished, so they get stop. Silent connections are dropped after it.
+const stopTimeout = time.Second
+
+// Coordinator splits the search tree into subtrees and hands them out to
+// workers connected over the network. Workers report back solutions, and the
+// best one is used as a bound for the following jobs and sent to the workers
+// busy with running ones.
+type Coordinator struct {
+	// Number of subtrees to split the search into.
+	// Zero value means DefaultSubtrees
+	Subtrees int
+	// Recursive threshold used by workers (see solver2.Solver)
+	RecursiveThreshold types.Index
+}
+
+// Solve solves the TSP problem with a given distance matrix, using workers
+// connecting to the listener. It returns when all subtrees are solved or the
+// context is canceled, and closes the listener in both cases. Once all
+// subtrees are solved, connections which do not ask for a job within
+// stopTimeout are dropped.
+func (c *Coordinator) Solve(ctx context.Context, l net.Listener, m [][]types.Distance) ([]types.Index, types.Distance, error) {
+	defer l.Close()
+
+	subtrees := c.Subtrees
+	if subtrees <= 0 {
+		subtrees = DefaultSubtrees
+	}
+
+	s := &solver2.Solver{}
+	jobs, path, distance, err := s.Split(m, subtrees)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r := newRun(jobs, path, distance)
+	wg := &sync.WaitGroup{}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.serve(r, conn, m)
+			}()
+		}
+	}()
+
+	finished := make(chan struct{})
+	go func() {
+		r.wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		r.expire(time.Now().Add(stopTimeout))
+	case <-ctx.Done():
+		r.cancel()
+		<-finished
+	}
+
+	l.Close()
+	wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.bestPath, r.bestDistance, ctx.Err()
+}
+
+// serve talks to a single worker until the search is finished
+func (c *Coordinator) serve(r *run, conn net.Conn, m [][]types.Distance) {
+	p := &peer{conn: conn, enc: gob.NewEncoder(conn)}
+	if !r.track(p) {
+		conn.Close()
+		return
+	}
+	defer r.untrack(p)
+
+	dec := gob.NewDecoder(conn)
+
+	err := p.send(message{Type: msgSetup, Matrix: m, Threshold: c.RecursiveThreshold})
+	if err != nil {
+		return
+	}
+
+	var current *tasks.Task
+	for {
+		var msg message
+		err := dec.Decode(&msg)
+		if err == nil && msg.Type == msgIncumbent && current != nil {
+			if r.improve(msg.Path, msg.Distance) {
+				r.broadcast(p, msg.Distance)
+			}
+			continue
+		}
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			// The search is over and the worker has not asked for a job
+			p.send(message{Type: msgStop})
+			return
+		}
+		if err != nil || msg.Type != msgRequest {
+			// The worker is gone, somebody else has to solve its job
+			if current != nil {
+				r.requeue(*current)
+			}
+			return
+		}
+
+		if current != nil {
+			r.complete(msg.Path, msg.Distance)
+			current = nil
+		}
+
+		task, bound, ok := r.next()
+		if !ok {
+			p.send(message{Type: msgStop})
+			return
+		}
+
+		current = &task
+		err = p.send(message{Type: msgJob, Task: task, Bound: bound})
+		if err != nil {
+			r.requeue(task)
+			return
+		}
+	}
+}
+
+// run is a shared state of a single distributed solve
+type run struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	// Subtrees left to solve, ordered by estimate
+	jobs        []tasks.Task
+	outstanding int
+	canceled    bool
+	peers       map[*peer]struct{}
+	// Read deadline of peers once the search is finished
+	deadline time.Time
+
+	bestPath     []types.Index
+	bestDistance types.Distance
+}
+
+func newRun(jobs []tasks.Task, path []types.Index, distance types.Distance) *run {
+	r := &run{
+		jobs:         jobs,
+		peers:        map[*peer]struct{}{},
+		bestPath:     path,
+		bestDistance: distance,
+	}
+	r.cond = sync.NewCond(&r.mu)
+
+	return r
+}
+
+// next returns the next job for a worker, waiting if all remaining jobs are
+// being solved by others (one of them may fail and return its job back)
+func (r *run) next() (tasks.Task, types.Distance, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for {
+		r.prune()
+		if r.canceled || (len(r.jobs) == 0 && r.outstanding == 0) {
+			return tasks.Task{}, 0, false
+		}
+
+		if len(r.jobs) != 0 {
+			task := r.jobs[0]
+			r.jobs = r.jobs[1:]
+			r.outstanding++
+			return task, r.bestDistance, true
+		}
+
+		r.cond.Wait()
+	}
+}
+
+// prune drops jobs which can not improve the best solution
+func (r *run) prune() {
+	if r.bestDistance == 0 {
+		return
+	}
+
+	count := 0
+	for _, task := range r.jobs {
+		if task.Estimate < r.bestDistance {
+			r.jobs[count] = task
+			count++
+		}
+	}
+	r.jobs = r.jobs[:count]
+}
+
+func (r *run) complete(path []types.Index, distance types.Distance) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.outstanding--
+	r.update(path, distance)
+	r.cond.Broadcast()
+}
+
+// improve saves a solution found by a running job and reports if it is
+// the new best one
+func (r *run) improve(path []types.Index, distance types.Distance) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.update(path, distance)
+}
+
+func (r *run) update(path []types.Index, distance types.Distance) bool {
+	if len(path) == 0 || (r.bestDistance != 0 && distance >= r.bestDistance) {
+		return false
+	}
+
+	r.bestPath = path
+	r.bestDistance = distance
+	return true
+}
+
+// broadcast sends the new bound to all workers except the one which found
+// it. Send errors are left to the goroutines serving the workers.
+func (r *run) broadcast(from *peer, bound types.Distance) {
+	r.mu.Lock()
+	peers := make([]*peer, 0, len(r.peers))
+	for p := range r.peers {
+		if p != from {
+			peers = append(peers, p)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, p := range peers {
+		p.send(message{Type: msgBound, Bound: bound})
+	}
+}
+
+func (r *run) requeue(task tasks.Task) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.outstanding--
+	// Failed job goes first, as it had the lowest estimate when handed out
+	r.jobs = append([]tasks.Task{task}, r.jobs...)
+	r.cond.Broadcast()
+}
+
+// wait blocks until all jobs are solved or the run is canceled
+func (r *run) wait() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for {
+		r.prune()
+		if r.canceled || (len(r.jobs) == 0 && r.outstanding == 0) {
+			return
+		}
+		r.cond.Wait()
+	}
+}
+
+// cancel stops the run, closing connections to busy workers
+func (r *run) cancel() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.canceled = true
+	for p := range r.peers {
+		p.conn.Close()
+	}
+	r.cond.Broadcast()
+}
+
+// expire sets the read deadline of all peers, including the ones
+// connected later
+func (r *run) expire(deadline time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deadline = deadline
+	for p := range r.peers {
+		p.conn.SetReadDeadline(deadline)
+	}
+}
+
+func (r *run) track(p *peer) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.canceled {
+		return false
+	}
+	if !r.deadline.IsZero() {
+		p.conn.SetReadDeadline(r.deadline)
+	}
+	r.peers[p] = struct{}{}
+	return true
+}
+
+func (r *run) untrack(p *peer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.peers, p)
+	p.conn.Close()
+}
+
+// peer is a connection to a worker. Besides its own goroutine, bounds are
+// sent to it by goroutines of other workers, so sends are serialized.
+type peer struct {
+	conn net.Conn
+
+	mu  sync.Mutex
+	enc *gob.Encoder
+}
+
+func (p *peer) send(msg message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.enc.Encode(msg)
+}
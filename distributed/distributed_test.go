@@ -0,0 +1,362 @@
+package distributed
+
+import (
+	"context"
+	"encoding/gob"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Spi1y/tsp-solver/solver2/tasks"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// Environment variable used to run the test binary as a worker process
+const workerEnv = "TSP_DISTRIBUTED_WORKER"
+
+func TestCoordinatorSolve(t *testing.T) {
+	for _, tt := range testCases() {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			assert.NoError(t, err)
+
+			// Workers are connected before solving starts, so none of them
+			// can miss the search
+			wg := &sync.WaitGroup{}
+			for i := 0; i < 3; i++ {
+				conn, err := net.Dial("tcp", l.Addr().String())
+				assert.NoError(t, err)
+
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					assert.NoError(t, Serve(context.Background(), conn))
+				}()
+			}
+
+			c := &Coordinator{Subtrees: 8}
+			path, dist, err := c.Solve(context.Background(), l, tt.matrix)
+			wg.Wait()
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.path, path)
+			assert.Equal(t, tt.dist, dist)
+		})
+	}
+}
+
+func TestCoordinatorSolveProcesses(t *testing.T) {
+	tt := testCases()[1]
+	address := filepath.Join(t.TempDir(), "tsp.sock")
+	l, err := net.Listen("unix", address)
+	assert.NoError(t, err)
+
+	var workers []*exec.Cmd
+	for i := 0; i < 3; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestHelperWorkerProcess$")
+		cmd.Env = append(os.Environ(), workerEnv+"=unix:"+address)
+		assert.NoError(t, cmd.Start())
+		workers = append(workers, cmd)
+	}
+
+	c := &Coordinator{Subtrees: 16, RecursiveThreshold: 3}
+	path, dist, err := c.Solve(context.Background(), l, tt.matrix)
+
+	assert.NoError(t, err)
+	assert.Equal(t, tt.path, path)
+	assert.Equal(t, tt.dist, dist)
+
+	for _, cmd := range workers {
+		assert.NoError(t, cmd.Wait())
+	}
+}
+
+// TestHelperWorkerProcess is not a real test, it is used as a worker process
+// by TestCoordinatorSolveProcesses
+func TestHelperWorkerProcess(t *testing.T) {
+	target := os.Getenv(workerEnv)
+	if target == "" {
+		t.Skip("Not started as a worker process")
+	}
+
+	parts := strings.SplitN(target, ":", 2)
+	conn, err := net.Dial(parts[0], parts[1])
+	if err != nil {
+		// Coordinator has finished before this worker started
+		return
+	}
+
+	err = Serve(context.Background(), conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCoordinatorWorkerFailure(t *testing.T) {
+	tt := testCases()[1]
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	// Broken worker takes a job and disconnects
+	conn, err := net.Dial("tcp", l.Addr().String())
+	assert.NoError(t, err)
+
+	brokenDone := make(chan struct{})
+	go func() {
+		defer close(brokenDone)
+		enc := gob.NewEncoder(conn)
+		dec := gob.NewDecoder(conn)
+
+		var msg message
+		assert.NoError(t, dec.Decode(&msg))
+		assert.NoError(t, enc.Encode(message{Type: msgRequest}))
+		assert.NoError(t, dec.Decode(&msg))
+		assert.Equal(t, msgJob, msg.Type)
+		conn.Close()
+	}()
+
+	result := make(chan error)
+	go func() {
+		<-brokenDone
+		result <- Work(context.Background(), "tcp", l.Addr().String())
+	}()
+
+	c := &Coordinator{Subtrees: 4}
+	path, dist, err := c.Solve(context.Background(), l, tt.matrix)
+
+	assert.NoError(t, err)
+	assert.NoError(t, <-result)
+	assert.Equal(t, tt.path, path)
+	assert.Equal(t, tt.dist, dist)
+}
+
+func TestCoordinatorSilentClient(t *testing.T) {
+	tt := testCases()[1]
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	// Silent client never asks for a job, it must not block the search
+	silent, err := net.Dial("tcp", l.Addr().String())
+	assert.NoError(t, err)
+	defer silent.Close()
+
+	result := make(chan error)
+	go func() {
+		result <- Work(context.Background(), "tcp", l.Addr().String())
+	}()
+
+	solved := make(chan struct{})
+	go func() {
+		defer close(solved)
+		c := &Coordinator{Subtrees: 4}
+		path, dist, err := c.Solve(context.Background(), l, tt.matrix)
+
+		assert.NoError(t, err)
+		assert.Equal(t, tt.path, path)
+		assert.Equal(t, tt.dist, dist)
+	}()
+
+	select {
+	case <-solved:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Solve is blocked by the silent client")
+	}
+	assert.NoError(t, <-result)
+
+	// Silent client is stopped too, after bounds of the worker solutions
+	dec := gob.NewDecoder(silent)
+	var msg message
+	assert.NoError(t, dec.Decode(&msg))
+	assert.Equal(t, msgSetup, msg.Type)
+	for msg.Type != msgStop {
+		msg = message{}
+		if !assert.NoError(t, dec.Decode(&msg)) {
+			return
+		}
+	}
+}
+
+func TestCoordinatorIncumbent(t *testing.T) {
+	tt := testCases()[1]
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	// Both workers take a job, then the second one finds the optimal solution
+	// while the first one is still busy
+	busy := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+
+	first, err := net.Dial("tcp", l.Addr().String())
+	assert.NoError(t, err)
+	go func() {
+		defer wg.Done()
+		enc := gob.NewEncoder(first)
+		dec := gob.NewDecoder(first)
+
+		var msg message
+		assert.NoError(t, dec.Decode(&msg))
+		assert.NoError(t, enc.Encode(message{Type: msgRequest}))
+		assert.NoError(t, dec.Decode(&msg))
+		assert.Equal(t, msgJob, msg.Type)
+		close(busy)
+
+		assert.NoError(t, dec.Decode(&msg))
+		assert.Equal(t, msgBound, msg.Type)
+		assert.Equal(t, tt.dist, msg.Bound)
+		idleWorker(t, enc, dec)
+	}()
+
+	second, err := net.Dial("tcp", l.Addr().String())
+	assert.NoError(t, err)
+	go func() {
+		defer wg.Done()
+		enc := gob.NewEncoder(second)
+		dec := gob.NewDecoder(second)
+
+		var msg message
+		assert.NoError(t, dec.Decode(&msg))
+		<-busy
+		assert.NoError(t, enc.Encode(message{Type: msgRequest}))
+		assert.NoError(t, dec.Decode(&msg))
+		assert.Equal(t, msgJob, msg.Type)
+
+		assert.NoError(t, enc.Encode(message{Type: msgIncumbent, Path: tt.path, Distance: tt.dist}))
+		idleWorker(t, enc, dec)
+	}()
+
+	c := &Coordinator{Subtrees: 4}
+	path, dist, err := c.Solve(context.Background(), l, tt.matrix)
+	wg.Wait()
+
+	assert.NoError(t, err)
+	assert.Equal(t, tt.path, path)
+	assert.Equal(t, tt.dist, dist)
+}
+
+func TestServeBound(t *testing.T) {
+	tt := testCases()[1]
+	coordinator, worker := net.Pipe()
+
+	result := make(chan error)
+	go func() {
+		result <- Serve(context.Background(), worker)
+	}()
+
+	enc := gob.NewEncoder(coordinator)
+	dec := gob.NewDecoder(coordinator)
+	assert.NoError(t, enc.Encode(message{Type: msgSetup, Matrix: tt.matrix}))
+
+	// Incumbents are reported while the job runs, the last one is optimal
+	var msg message
+	assert.NoError(t, dec.Decode(&msg))
+	assert.Equal(t, msgRequest, msg.Type)
+	assert.NoError(t, enc.Encode(message{Type: msgJob, Task: tasks.Task{Path: []types.Index{0}}}))
+
+	var incumbents []types.Distance
+	for {
+		msg = message{}
+		assert.NoError(t, dec.Decode(&msg))
+		if msg.Type != msgIncumbent {
+			break
+		}
+		incumbents = append(incumbents, msg.Distance)
+	}
+	assert.Equal(t, msgRequest, msg.Type)
+	assert.Equal(t, tt.path, msg.Path)
+	assert.NotEmpty(t, incumbents)
+	assert.Equal(t, tt.dist, incumbents[len(incumbents)-1])
+
+	// Bound of a solution found elsewhere applies to the following jobs
+	assert.NoError(t, enc.Encode(message{Type: msgBound, Bound: tt.dist}))
+	assert.NoError(t, enc.Encode(message{Type: msgJob, Task: tasks.Task{Path: []types.Index{0}}}))
+	// Gob leaves fields missing in the stream as they are
+	msg = message{}
+	assert.NoError(t, dec.Decode(&msg))
+	assert.Equal(t, msgRequest, msg.Type)
+	assert.Empty(t, msg.Path)
+
+	assert.NoError(t, enc.Encode(message{Type: msgStop}))
+	assert.NoError(t, <-result)
+}
+
+func TestCoordinatorCancel(t *testing.T) {
+	tt := testCases()[1]
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	// No workers are connected, so only cancellation stops the search
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	c := &Coordinator{Subtrees: 4}
+	_, _, err = c.Solve(ctx, l, tt.matrix)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+// idleWorker answers jobs with empty results until the coordinator sends
+// stop, as if none of the jobs had better solutions
+func idleWorker(t *testing.T, enc *gob.Encoder, dec *gob.Decoder) {
+	for {
+		assert.NoError(t, enc.Encode(message{Type: msgRequest}))
+
+		var msg message
+		for {
+			if !assert.NoError(t, dec.Decode(&msg)) {
+				return
+			}
+			if msg.Type != msgBound {
+				break
+			}
+		}
+		if msg.Type == msgStop {
+			return
+		}
+		assert.Equal(t, msgJob, msg.Type)
+	}
+}
+
+type testCase struct {
+	name   string
+	matrix [][]types.Distance
+	path   []types.Index
+	dist   types.Distance
+}
+
+func testCases() []testCase {
+	return []testCase{
+		{
+			"Real case - 4 points",
+			[][]types.Distance{
+				{0, 15_147, 4_596, 10_263, 5_482},
+				{17_465, 0, 19_314, 21_477, 20_619},
+				{4_643, 20_347, 0, 6_918, 1_340},
+				{10_506, 21_310, 7_257, 0, 6_089},
+				{6_585, 20_577, 1_340, 6_199, 0},
+			},
+			[]types.Index{0, 1, 3, 4, 2, 0},
+			48_696,
+		},
+		{
+			"Real case - 7 points",
+			[][]types.Distance{
+				{0, 15147, 21742, 12730, 18594, 6147, 6955, 10000},
+				{17465, 0, 30524, 22534, 27376, 20763, 15326, 21214},
+				{23594, 43627, 0, 16165, 9604, 21957, 18560, 21180},
+				{11103, 22595, 16255, 0, 10210, 5909, 7880, 3274},
+				{19133, 27796, 9754, 10054, 0, 12856, 14099, 10486},
+				{6155, 21069, 23218, 7694, 14520, 0, 5419, 4964},
+				{5736, 14952, 18081, 8492, 14933, 6300, 0, 7172},
+				{10801, 21605, 17131, 4504, 11197, 3615, 6890, 0},
+			},
+			[]types.Index{0, 1, 6, 2, 4, 3, 7, 5, 0},
+			81_256,
+		},
+	}
+}
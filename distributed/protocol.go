@@ -0,0 +1,52 @@
+package distributed
+
+import (
+	"errors"
+
+	"github.com/Spi1y/tsp-solver/solver2/tasks"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Protocol is a sequence of gob encoded messages over a stream connection:
+//
+//	coordinator -> worker: setup
+//	worker -> coordinator: request (with the result of the previous job, if any)
+//	coordinator -> worker: job or stop
+//
+// Request/job pairs are repeated until the coordinator sends stop. While
+// a job runs, the worker reports every solution it improves on with an
+// incumbent message, and the coordinator sends a bound message to the other
+// workers when the best solution improves.
+type messageType int
+
+const (
+	msgSetup messageType = iota
+	msgRequest
+	msgJob
+	msgStop
+	msgIncumbent
+	msgBound
+)
+
+// message is a single protocol message. Only fields relevant to the
+// message type are set.
+type message struct {
+	Type messageType
+
+	// Setup
+	Matrix    [][]types.Distance
+	Threshold types.Index
+
+	// Job and bound. The task is the root of a subtree to solve, and only
+	// solutions shorter than the bound are of interest
+	Task  tasks.Task
+	Bound types.Distance
+
+	// Request and incumbent. Path of a request is empty if the previous job
+	// had no better solutions
+	Path     []types.Index
+	Distance types.Distance
+}
+
+// errProtocol is returned by workers on unexpected messages
+var errProtocol = errors.New("Unexpected message from the coordinator")
@@ -0,0 +1,145 @@
+package distributed
+
+import (
+	"context"
+	"encoding/gob"
+	"net"
+	"sync/atomic"
+
+	"github.com/Spi1y/tsp-solver/solver2"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Work connects to the coordinator and solves subtrees handed out by it,
+// until the coordinator sends stop or the context is canceled
+func Work(ctx context.Context, network, address string) error {
+	d := net.Dialer{}
+	conn, err := d.DialContext(ctx, network, address)
+	if err != nil {
+		return err
+	}
+
+	return Serve(ctx, conn)
+}
+
+// Serve runs the worker over an established connection and closes it at the end
+func Serve(ctx context.Context, conn net.Conn) error {
+	defer conn.Close()
+
+	// Unblocking reads and writes on cancellation
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	enc := gob.NewEncoder(conn)
+	dec := gob.NewDecoder(conn)
+
+	var setup message
+	if err := dec.Decode(&setup); err != nil {
+		return orContextErr(ctx, err)
+	}
+	if setup.Type != msgSetup {
+		return errProtocol
+	}
+
+	// Bound messages may come at any time, so they are read in the background
+	// and only the best bound is kept for the search
+	var bound atomic.Uint32
+	msgs := make(chan message)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			var msg message
+			if err := dec.Decode(&msg); err != nil {
+				readErr <- err
+				return
+			}
+			if msg.Type == msgBound {
+				lower(&bound, msg.Bound)
+				continue
+			}
+
+			select {
+			case msgs <- msg:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var sendErr error
+	s := &solver2.Solver{
+		RecursiveThreshold: setup.Threshold,
+		Bound:              bound.Load,
+		OnIncumbent: func(path []types.Index, distance types.Distance) {
+			if sendErr == nil {
+				sendErr = enc.Encode(message{Type: msgIncumbent, Path: path, Distance: distance})
+			}
+		},
+	}
+	request := message{Type: msgRequest}
+
+	for {
+		if err := enc.Encode(request); err != nil {
+			return orContextErr(ctx, err)
+		}
+
+		var msg message
+		select {
+		case msg = <-msgs:
+		case err := <-readErr:
+			return orContextErr(ctx, err)
+		}
+
+		switch msg.Type {
+		case msgStop:
+			return nil
+		case msgJob:
+		default:
+			return errProtocol
+		}
+
+		lower(&bound, msg.Bound)
+		path, distance, _, err := s.SolveFrom(ctx, setup.Matrix, msg.Task, msg.Bound)
+		if err != nil {
+			return err
+		}
+		if sendErr != nil {
+			return orContextErr(ctx, sendErr)
+		}
+
+		request = message{
+			Type:     msgRequest,
+			Path:     path,
+			Distance: distance,
+		}
+	}
+}
+
+// lower replaces the bound with a lower one, zero means no bound
+func lower(bound *atomic.Uint32, value types.Distance) {
+	for {
+		current := bound.Load()
+		if (value == 0) || ((current != 0) && (current <= value)) {
+			return
+		}
+		if bound.CompareAndSwap(current, value) {
+			return
+		}
+	}
+}
+
+// orContextErr replaces network errors caused by cancellation with the context error
+func orContextErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
+}
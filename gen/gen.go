@@ -0,0 +1,267 @@
+// Package gen generates random TSP instances for testing and benchmarking.
+// All generators are deterministic for a given seed. Distances between
+// different nodes are never zero, as solvers treat zero distance solutions
+// as missing.
+package gen
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/Spi1y/tsp-solver/problem"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// DefaultMaxDistance is the default upper limit of generated distances
+const DefaultMaxDistance = 10_000
+
+// DefaultSkew is the default share of distance variation for Asymmetric
+// and Road instances
+const DefaultSkew = 0.3
+
+// Kind is a family of random instances
+type Kind int
+
+const (
+	// Uniform instances have independent symmetric distances
+	Uniform Kind = iota
+	// Euclidean instances have nodes uniformly placed on a plane
+	Euclidean
+	// Clustered instances have nodes grouped around several centers
+	Clustered
+	// Asymmetric instances are Euclidean ones with distances randomly
+	// shortened in each direction separately
+	Asymmetric
+	// Ties instances have distances from a tiny range, so there are many
+	// optimal solutions
+	Ties
+	// Grid instances have nodes in cells of a square grid with Manhattan
+	// distances
+	Grid
+	// Road instances are Euclidean ones with random symmetric detours,
+	// so the triangle inequality is violated
+	Road
+)
+
+// Kinds lists all instance kinds
+var Kinds = []Kind{Uniform, Euclidean, Clustered, Asymmetric, Ties, Grid, Road}
+
+// String implements the Stringer interface
+func (k Kind) String() string {
+	switch k {
+	case Uniform:
+		return "uniform"
+	case Euclidean:
+		return "euclidean"
+	case Clustered:
+		return "clustered"
+	case Asymmetric:
+		return "asymmetric"
+	case Ties:
+		return "ties"
+	case Grid:
+		return "grid"
+	case Road:
+		return "road"
+	}
+
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// ParseKind returns the kind by its name
+func ParseKind(name string) (Kind, error) {
+	for _, k := range Kinds {
+		if k.String() == name {
+			return k, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Unknown instance kind %q", name)
+}
+
+// Options of the generated instance
+type Options struct {
+	Kind Kind
+	// Number of nodes, including the starting one
+	Size int
+	Seed int64
+	// Upper limit of distances. Zero value means DefaultMaxDistance
+	MaxDistance types.Distance
+	// Share of distance variation for Asymmetric and Road kinds, from 0 to 1.
+	// Nil means DefaultSkew, so zero skew can be requested explicitly
+	Skew *float64
+}
+
+// Generate generates a random distance matrix
+func Generate(o Options) ([][]types.Distance, error) {
+	if (o.Size <= 0) || (o.Size > math.MaxUint8+1) {
+		return nil, fmt.Errorf("Invalid instance size %d", o.Size)
+	}
+	if o.MaxDistance == 0 {
+		o.MaxDistance = DefaultMaxDistance
+	}
+	variation := DefaultSkew
+	if o.Skew != nil {
+		variation = *o.Skew
+	}
+	if (variation < 0) || (variation > 1) {
+		return nil, fmt.Errorf("Invalid skew %v", variation)
+	}
+
+	r := rand.New(rand.NewSource(o.Seed))
+
+	switch o.Kind {
+	case Uniform:
+		return uniform(r, o.Size, 1, o.MaxDistance), nil
+	case Ties:
+		return uniform(r, o.Size, 1, 3), nil
+	case Euclidean:
+		return planar(box(r, o.Size, o.MaxDistance), nil)
+	case Clustered:
+		return planar(clusters(r, o.Size, o.MaxDistance), nil)
+	case Asymmetric:
+		m, err := planar(box(r, o.Size, o.MaxDistance), nil)
+		if err != nil {
+			return nil, err
+		}
+		skew(r, m, variation)
+		return m, nil
+	case Grid:
+		return planar(grid(r, o.Size, o.MaxDistance), problem.Manhattan)
+	case Road:
+		// Box is shrunk to keep detoured distances within the limit
+		limit := types.Distance(float64(o.MaxDistance) / (1 + variation))
+		m, err := planar(box(r, o.Size, limit), nil)
+		if err != nil {
+			return nil, err
+		}
+		detour(r, m, variation)
+		return m, nil
+	}
+
+	return nil, fmt.Errorf("Unknown instance kind %d", o.Kind)
+}
+
+// uniform generates symmetric distances uniformly distributed in [low, high]
+func uniform(r *rand.Rand, size int, low, high types.Distance) [][]types.Distance {
+	m := newMatrix(size)
+	for i := 0; i < size; i++ {
+		for j := i + 1; j < size; j++ {
+			val := low + types.Distance(r.Int63n(int64(high-low)+1))
+			m[i][j] = val
+			m[j][i] = val
+		}
+	}
+
+	return m
+}
+
+// box places nodes uniformly in a square with a side making distances
+// fit into the limit
+func box(r *rand.Rand, size int, limit types.Distance) []problem.Node {
+	side := float64(limit) / math.Sqrt2
+	nodes := make([]problem.Node, size)
+	for i := range nodes {
+		nodes[i].X = r.Float64() * side
+		nodes[i].Y = r.Float64() * side
+	}
+
+	return nodes
+}
+
+// clusters places nodes normally distributed around several random centers
+func clusters(r *rand.Rand, size int, limit types.Distance) []problem.Node {
+	count := 1 + size/5
+	centers := box(r, count, limit)
+	spread := float64(limit) / math.Sqrt2 / 20
+	side := float64(limit) / math.Sqrt2
+
+	nodes := make([]problem.Node, size)
+	for i := range nodes {
+		c := centers[r.Intn(count)]
+		nodes[i].X = clamp(c.X+r.NormFloat64()*spread, 0, side)
+		nodes[i].Y = clamp(c.Y+r.NormFloat64()*spread, 0, side)
+	}
+
+	return nodes
+}
+
+// grid places nodes into random cells of a square grid
+func grid(r *rand.Rand, size int, limit types.Distance) []problem.Node {
+	side := int(math.Ceil(math.Sqrt(float64(size))))
+	step := 1.0
+	if side > 1 {
+		step = float64(limit) / float64(2*(side-1))
+	}
+
+	cells := r.Perm(side * side)
+	nodes := make([]problem.Node, size)
+	for i := range nodes {
+		nodes[i].X = float64(cells[i]%side) * step
+		nodes[i].Y = float64(cells[i]/side) * step
+	}
+
+	return nodes
+}
+
+// planar builds a Euclidean matrix. Co-located nodes get distance 1
+func planar(nodes []problem.Node, distance problem.DistanceFunc) ([][]types.Distance, error) {
+	p := problem.Problem{Nodes: nodes, Distance: distance, Rounding: problem.RoundUp}
+	m, err := p.Distances()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range m {
+		for j := range m[i] {
+			if (i != j) && (m[i][j] == 0) {
+				m[i][j] = 1
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// skew shortens each directed distance by a random amount up to a given share
+func skew(r *rand.Rand, m [][]types.Distance, share float64) {
+	for i := range m {
+		for j := range m[i] {
+			if i == j {
+				continue
+			}
+			val := types.Distance(float64(m[i][j]) * (1 - share*r.Float64()))
+			if val == 0 {
+				val = 1
+			}
+			m[i][j] = val
+		}
+	}
+}
+
+// detour lengthens each distance by a random amount up to a given share,
+// keeping the matrix symmetric
+func detour(r *rand.Rand, m [][]types.Distance, share float64) {
+	for i := range m {
+		for j := i + 1; j < len(m); j++ {
+			val := types.Distance(float64(m[i][j]) * (1 + share*r.Float64()))
+			m[i][j] = val
+			m[j][i] = val
+		}
+	}
+}
+
+func newMatrix(size int) [][]types.Distance {
+	backingArray := make([]types.Distance, size*size)
+	m := make([][]types.Distance, size)
+	for i := range m {
+		m[i] = backingArray[i*size : (i+1)*size]
+	}
+
+	return m
+}
+
+func clamp(val, low, high float64) float64 {
+	return math.Max(low, math.Min(high, val))
+}
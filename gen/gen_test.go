@@ -0,0 +1,119 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	for _, kind := range Kinds {
+		t.Run(kind.String(), func(t *testing.T) {
+			o := Options{Kind: kind, Size: 12, Seed: 42, MaxDistance: 1_000}
+			m, err := Generate(o)
+			assert.NoError(t, err)
+			assert.Len(t, m, o.Size)
+
+			symmetric := true
+			for i := range m {
+				assert.Len(t, m[i], o.Size)
+				for j := range m[i] {
+					if i == j {
+						assert.Zero(t, m[i][j])
+						continue
+					}
+					assert.NotZero(t, m[i][j])
+					assert.LessOrEqual(t, m[i][j], o.MaxDistance)
+					symmetric = symmetric && (m[i][j] == m[j][i])
+				}
+			}
+			assert.Equal(t, kind != Asymmetric, symmetric)
+
+			// Same seed gives the same instance
+			again, err := Generate(o)
+			assert.NoError(t, err)
+			assert.Equal(t, m, again)
+
+			o.Seed++
+			other, err := Generate(o)
+			assert.NoError(t, err)
+			assert.NotEqual(t, m, other)
+		})
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	_, err := Generate(Options{Size: 0})
+	assert.Error(t, err)
+	_, err = Generate(Options{Size: 257})
+	assert.Error(t, err)
+	_, err = Generate(Options{Size: 5, Kind: Kind(42)})
+	assert.Error(t, err)
+	invalid := 2.0
+	_, err = Generate(Options{Size: 5, Kind: Asymmetric, Skew: &invalid})
+	assert.Error(t, err)
+
+	m, err := Generate(Options{Size: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]types.Distance{{0}}, m)
+}
+
+func TestParseKind(t *testing.T) {
+	for _, kind := range Kinds {
+		parsed, err := ParseKind(kind.String())
+		assert.NoError(t, err)
+		assert.Equal(t, kind, parsed)
+	}
+
+	_, err := ParseKind("unknown")
+	assert.Error(t, err)
+	assert.Equal(t, "Kind(42)", Kind(42).String())
+}
+
+func TestGenerateZeroSkew(t *testing.T) {
+	skew := 0.0
+	m, err := Generate(Options{Kind: Asymmetric, Size: 10, Seed: 1, Skew: &skew})
+	assert.NoError(t, err)
+	for i := range m {
+		for j := range m {
+			assert.Equal(t, m[i][j], m[j][i])
+		}
+	}
+
+	// Default skew makes distances asymmetric
+	m, err = Generate(Options{Kind: Asymmetric, Size: 10, Seed: 1})
+	assert.NoError(t, err)
+	assert.NotEqual(t, m[0][1], m[1][0])
+}
+
+func TestGenerateRoad(t *testing.T) {
+	skew := 0.5
+	m, err := Generate(Options{Kind: Road, Size: 30, Seed: 1, Skew: &skew})
+	assert.NoError(t, err)
+
+	// Some shortcuts through a third node are shorter than direct roads
+	violations := 0
+	for i := range m {
+		for j := range m {
+			for k := range m {
+				if (i != j) && (j != k) && (i != k) && (m[i][k]+m[k][j] < m[i][j]) {
+					violations++
+				}
+			}
+		}
+	}
+	assert.NotZero(t, violations)
+}
+
+func TestGenerateGrid(t *testing.T) {
+	m, err := Generate(Options{Kind: Grid, Size: 9, Seed: 1, MaxDistance: 100})
+	assert.NoError(t, err)
+
+	// 3x3 grid with a step of 25
+	for i := range m {
+		for j := range m[i] {
+			assert.Zero(t, m[i][j]%25)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package gen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Format of instance files
+type Format int
+
+const (
+	// JSON is a native format of the project
+	JSON Format = iota
+	// TSPLIB is a format of the TSPLIB library
+	TSPLIB
+)
+
+// Instance is a TSP instance with an optional known optimum
+type Instance struct {
+	Name    string             `json:"name"`
+	Comment string             `json:"comment,omitempty"`
+	Matrix  [][]types.Distance `json:"matrix"`
+	// Optimal distance, zero if it is unknown
+	Optimum types.Distance `json:"optimum,omitempty"`
+}
+
+// NewInstance generates a random instance
+func NewInstance(o Options) (*Instance, error) {
+	if o.MaxDistance == 0 {
+		o.MaxDistance = DefaultMaxDistance
+	}
+
+	m, err := Generate(o)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Instance{
+		Name:    fmt.Sprintf("%s-%d-%d", o.Kind, o.Size, o.Seed),
+		Comment: fmt.Sprintf("kind %s, size %d, seed %d, max distance %d", o.Kind, o.Size, o.Seed, o.MaxDistance),
+		Matrix:  m,
+	}, nil
+}
+
+// FormatOf returns the format by the file extension.
+// Files with .tsp and .atsp extensions are TSPLIB ones.
+func FormatOf(name string) Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".tsp", ".atsp":
+		return TSPLIB
+	}
+
+	return JSON
+}
+
+// Write encodes the instance in the given format
+func (inst *Instance) Write(w io.Writer, f Format) error {
+	switch f {
+	case JSON:
+		return json.NewEncoder(w).Encode(inst)
+	case TSPLIB:
+		return writeTSPLIB(w, inst)
+	}
+
+	return fmt.Errorf("Unknown format %d", f)
+}
+
+// Read decodes an instance in the given format
+func Read(r io.Reader, f Format) (*Instance, error) {
+	var inst *Instance
+	var err error
+
+	switch f {
+	case JSON:
+		inst = &Instance{}
+		err = json.NewDecoder(r).Decode(inst)
+	case TSPLIB:
+		inst, err = readTSPLIB(r)
+	default:
+		err = fmt.Errorf("Unknown format %d", f)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(inst.Matrix) == 0 {
+		return nil, errors.New("Instance has no nodes")
+	}
+	for i := range inst.Matrix {
+		if len(inst.Matrix[i]) != len(inst.Matrix) {
+			return nil, errors.New("Distance matrix is not square")
+		}
+	}
+
+	return inst, nil
+}
+
+// WriteFile saves the instance to a file in the format defined by extension
+func (inst *Instance) WriteFile(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	err = inst.Write(f, FormatOf(name))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// ReadFile loads an instance from a file in the format defined by extension.
+// If the instance has no name, the file name is used.
+func ReadFile(name string) (*Instance, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	inst, err := Read(f, FormatOf(name))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if inst.Name == "" {
+		inst.Name = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	}
+
+	return inst, nil
+}
@@ -0,0 +1,150 @@
+package gen
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstance_WriteRead(t *testing.T) {
+	for _, kind := range []Kind{Euclidean, Asymmetric} {
+		inst, err := NewInstance(Options{Kind: kind, Size: 7, Seed: 3})
+		assert.NoError(t, err)
+		inst.Optimum = 42
+
+		for _, f := range []Format{JSON, TSPLIB} {
+			var buf bytes.Buffer
+			assert.NoError(t, inst.Write(&buf, f))
+
+			restored, err := Read(&buf, f)
+			assert.NoError(t, err)
+			assert.Equal(t, inst.Name, restored.Name)
+			assert.Equal(t, inst.Comment, restored.Comment)
+			assert.Equal(t, inst.Matrix, restored.Matrix)
+			if f == JSON {
+				assert.Equal(t, inst.Optimum, restored.Optimum)
+			}
+		}
+	}
+}
+
+func TestWriteTSPLIB(t *testing.T) {
+	inst := &Instance{Name: "asym", Matrix: [][]types.Distance{{0, 1}, {2, 0}}}
+
+	var buf bytes.Buffer
+	assert.NoError(t, inst.Write(&buf, TSPLIB))
+	assert.Equal(t, `NAME: asym
+TYPE: ATSP
+DIMENSION: 2
+EDGE_WEIGHT_TYPE: EXPLICIT
+EDGE_WEIGHT_FORMAT: FULL_MATRIX
+EDGE_WEIGHT_SECTION
+0 1
+2 0
+EOF
+`, buf.String())
+}
+
+func TestReadTSPLIB(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    [][]types.Distance
+		wantErr bool
+	}{
+		{
+			"Full matrix",
+			"NAME : br3\nTYPE : ATSP\nDIMENSION : 3\nEDGE_WEIGHT_TYPE : EXPLICIT\nEDGE_WEIGHT_FORMAT : FULL_MATRIX\nEDGE_WEIGHT_SECTION\n9999 1 2\n3 9999\n4 5 6 9999\nEOF\n",
+			[][]types.Distance{{0, 1, 2}, {3, 0, 4}, {5, 6, 0}},
+			false,
+		},
+		{
+			"Coordinates",
+			"NAME: tri\nTYPE: TSP\nDIMENSION: 3\nEDGE_WEIGHT_TYPE: EUC_2D\nNODE_COORD_SECTION\n1 0 0\n2 3 4\n3 0 4.4\nEOF\n",
+			[][]types.Distance{{0, 5, 4}, {5, 0, 3}, {4, 3, 0}},
+			false,
+		},
+		{
+			"Ceil coordinates",
+			"DIMENSION: 2\nEDGE_WEIGHT_TYPE: CEIL_2D\nNODE_COORD_SECTION\n1 0 0\n2 0 4.4\n",
+			[][]types.Distance{{0, 5}, {5, 0}},
+			false,
+		},
+		{
+			"Missing weights",
+			"DIMENSION: 2\nEDGE_WEIGHT_TYPE: EXPLICIT\nEDGE_WEIGHT_FORMAT: FULL_MATRIX\nEDGE_WEIGHT_SECTION\n0 1 2\n",
+			nil,
+			true,
+		},
+		{
+			"Unsupported format",
+			"DIMENSION: 2\nEDGE_WEIGHT_TYPE: EXPLICIT\nEDGE_WEIGHT_FORMAT: UPPER_ROW\nEDGE_WEIGHT_SECTION\n1\n",
+			nil,
+			true,
+		},
+		{
+			"Unsupported type",
+			"DIMENSION: 2\nEDGE_WEIGHT_TYPE: GEO\nNODE_COORD_SECTION\n1 0 0\n2 1 1\n",
+			nil,
+			true,
+		},
+		{
+			"No dimension",
+			"EDGE_WEIGHT_TYPE: EUC_2D\n",
+			nil,
+			true,
+		},
+		{
+			"Garbage",
+			"1 2 3\n",
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst, err := Read(strings.NewReader(tt.in), TSPLIB)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, inst.Matrix)
+		})
+	}
+}
+
+func TestInstance_WriteReadFile(t *testing.T) {
+	dir := t.TempDir()
+	inst := &Instance{Matrix: [][]types.Distance{{0, 1}, {1, 0}}, Optimum: 2}
+
+	for _, name := range []string{"small.json", "small.tsp"} {
+		name = filepath.Join(dir, name)
+		assert.NoError(t, inst.WriteFile(name))
+
+		restored, err := ReadFile(name)
+		assert.NoError(t, err)
+		assert.Equal(t, "small", restored.Name)
+		assert.Equal(t, inst.Matrix, restored.Matrix)
+	}
+
+	_, err := ReadFile(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+
+	_, err = Read(strings.NewReader(`{"matrix": [[0, 1]]}`), JSON)
+	assert.Error(t, err)
+	_, err = Read(strings.NewReader(`{}`), JSON)
+	assert.Error(t, err)
+}
+
+func TestFormatOf(t *testing.T) {
+	assert.Equal(t, JSON, FormatOf("a.json"))
+	assert.Equal(t, TSPLIB, FormatOf("dir/br17.ATSP"))
+	assert.Equal(t, TSPLIB, FormatOf("a.tsp"))
+	assert.Equal(t, JSON, FormatOf("noext"))
+}
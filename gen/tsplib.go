@@ -0,0 +1,172 @@
+package gen
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Spi1y/tsp-solver/problem"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// writeTSPLIB writes the instance as an explicit full matrix
+func writeTSPLIB(w io.Writer, inst *Instance) error {
+	bw := bufio.NewWriter(w)
+
+	kind := "TSP"
+	if !symmetric(inst.Matrix) {
+		kind = "ATSP"
+	}
+
+	fmt.Fprintf(bw, "NAME: %s\n", inst.Name)
+	fmt.Fprintf(bw, "TYPE: %s\n", kind)
+	if inst.Comment != "" {
+		fmt.Fprintf(bw, "COMMENT: %s\n", inst.Comment)
+	}
+	fmt.Fprintf(bw, "DIMENSION: %d\n", len(inst.Matrix))
+	fmt.Fprintf(bw, "EDGE_WEIGHT_TYPE: EXPLICIT\n")
+	fmt.Fprintf(bw, "EDGE_WEIGHT_FORMAT: FULL_MATRIX\n")
+	fmt.Fprintf(bw, "EDGE_WEIGHT_SECTION\n")
+	for _, row := range inst.Matrix {
+		for j, val := range row {
+			if j != 0 {
+				bw.WriteByte(' ')
+			}
+			bw.WriteString(strconv.FormatUint(uint64(val), 10))
+		}
+		bw.WriteByte('\n')
+	}
+	fmt.Fprintf(bw, "EOF\n")
+
+	return bw.Flush()
+}
+
+// readTSPLIB reads an instance with either an explicit full matrix
+// or 2D node coordinates
+func readTSPLIB(r io.Reader) (*Instance, error) {
+	inst := &Instance{}
+	header := map[string]string{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var section string
+	var values []string
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if line == "EOF" {
+			break
+		}
+
+		if key, val, ok := strings.Cut(line, ":"); ok {
+			header[strings.TrimSpace(key)] = strings.TrimSpace(val)
+			section = ""
+			continue
+		}
+		if strings.HasSuffix(line, "_SECTION") {
+			section = line
+			continue
+		}
+		if section == "" {
+			return nil, fmt.Errorf("Unexpected line %q", line)
+		}
+		if section == "EDGE_WEIGHT_SECTION" || section == "NODE_COORD_SECTION" {
+			values = append(values, strings.Fields(line)...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	inst.Name = header["NAME"]
+	inst.Comment = header["COMMENT"]
+	size, err := strconv.Atoi(header["DIMENSION"])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid dimension %q", header["DIMENSION"])
+	}
+
+	switch header["EDGE_WEIGHT_TYPE"] {
+	case "EXPLICIT":
+		if format := header["EDGE_WEIGHT_FORMAT"]; format != "FULL_MATRIX" {
+			return nil, fmt.Errorf("Unsupported edge weight format %q", format)
+		}
+		inst.Matrix, err = parseFullMatrix(values, size)
+	case "EUC_2D":
+		inst.Matrix, err = parseCoords(values, size, problem.Euclidean, problem.RoundNearest)
+	case "CEIL_2D":
+		inst.Matrix, err = parseCoords(values, size, problem.Euclidean, problem.RoundUp)
+	case "MAN_2D":
+		inst.Matrix, err = parseCoords(values, size, problem.Manhattan, problem.RoundNearest)
+	default:
+		err = fmt.Errorf("Unsupported edge weight type %q", header["EDGE_WEIGHT_TYPE"])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return inst, nil
+}
+
+func parseFullMatrix(values []string, size int) ([][]types.Distance, error) {
+	if len(values) != size*size {
+		return nil, fmt.Errorf("Expected %d edge weights, got %d", size*size, len(values))
+	}
+
+	m := newMatrix(size)
+	for i := range values {
+		val, err := strconv.ParseUint(values[i], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		m[i/size][i%size] = types.Distance(val)
+	}
+
+	// TSPLIB often uses big values on the diagonal
+	for i := range m {
+		m[i][i] = 0
+	}
+
+	return m, nil
+}
+
+func parseCoords(values []string, size int, distance problem.DistanceFunc, rounding problem.Rounding) ([][]types.Distance, error) {
+	if len(values) != size*3 {
+		return nil, fmt.Errorf("Expected %d node coordinates, got %d", size, len(values)/3)
+	}
+
+	p := problem.Problem{
+		Nodes:    make([]problem.Node, size),
+		Distance: distance,
+		Rounding: rounding,
+	}
+	for i := range p.Nodes {
+		x, err := strconv.ParseFloat(values[i*3+1], 64)
+		if err != nil {
+			return nil, err
+		}
+		y, err := strconv.ParseFloat(values[i*3+2], 64)
+		if err != nil {
+			return nil, err
+		}
+		p.Nodes[i] = problem.Node{ID: values[i*3], X: x, Y: y}
+	}
+
+	return p.Distances()
+}
+
+func symmetric(m [][]types.Distance) bool {
+	for i := range m {
+		for j := i + 1; j < len(m); j++ {
+			if m[i][j] != m[j][i] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,299 @@
+package little
+
+import (
+	"math"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// inf marks removed and forbidden elements of the reduced matrix
+const inf = math.MaxUint32
+
+// noNode marks absent links of the included edges
+const noNode = -1
+
+// node is a node of the search tree: a set of included edges, a set of
+// excluded ones (as inf elements) and the reduced matrix with its bound
+type node struct {
+	size int
+	// Reduced matrix row by row. Rows of nodes with an included outgoing
+	// edge and columns of nodes with an included incoming edge are removed,
+	// they are skipped, not cleared.
+	matrix []types.Distance
+	// Included edges as successors and predecessors of nodes
+	next []int16
+	prev []int16
+	// Number of included edges
+	included int
+	// Lower bound of all tours in the subtree
+	bound uint64
+}
+
+// newRoot creates the root node for the distance matrix
+func newRoot(m [][]types.Distance) *node {
+	size := len(m)
+	n := &node{
+		size:   size,
+		matrix: make([]types.Distance, size*size),
+		next:   make([]int16, size),
+		prev:   make([]int16, size),
+	}
+
+	for i, row := range m {
+		copy(n.matrix[i*size:(i+1)*size], row)
+		n.matrix[i*size+i] = inf
+		n.next[i] = noNode
+		n.prev[i] = noNode
+	}
+	n.reduce()
+
+	return n
+}
+
+// copy creates a child node with the same state
+func (n *node) copy() *node {
+	c := &node{
+		size:     n.size,
+		matrix:   make([]types.Distance, len(n.matrix)),
+		next:     make([]int16, n.size),
+		prev:     make([]int16, n.size),
+		included: n.included,
+		bound:    n.bound,
+	}
+	copy(c.matrix, n.matrix)
+	copy(c.next, n.next)
+	copy(c.prev, n.prev)
+
+	return c
+}
+
+// reduce subtracts row and then column minimums from active elements,
+// adding them to the bound. The bound becomes inf if some row or column
+// has no allowed elements left.
+func (n *node) reduce() {
+	size := n.size
+
+	for i := 0; i < size; i++ {
+		if n.next[i] != noNode {
+			continue
+		}
+
+		row := n.matrix[i*size : (i+1)*size]
+		min := types.Distance(inf)
+		for j, val := range row {
+			if (n.prev[j] == noNode) && (val < min) {
+				min = val
+			}
+		}
+		if !n.add(min) {
+			return
+		}
+		if min == 0 {
+			continue
+		}
+
+		for j, val := range row {
+			if (n.prev[j] == noNode) && (val != inf) {
+				row[j] = val - min
+			}
+		}
+	}
+
+	for j := 0; j < size; j++ {
+		if n.prev[j] != noNode {
+			continue
+		}
+
+		min := types.Distance(inf)
+		for i := 0; i < size; i++ {
+			if val := n.matrix[i*size+j]; (n.next[i] == noNode) && (val < min) {
+				min = val
+			}
+		}
+		if !n.add(min) {
+			return
+		}
+		if min == 0 {
+			continue
+		}
+
+		for i := 0; i < size; i++ {
+			if val := n.matrix[i*size+j]; (n.next[i] == noNode) && (val != inf) {
+				n.matrix[i*size+j] = val - min
+			}
+		}
+	}
+}
+
+// add adds a reduction constant to the bound. It returns false and makes
+// the node infeasible if the constant is inf.
+func (n *node) add(val types.Distance) bool {
+	if val == inf {
+		n.bound = math.MaxUint64
+		return false
+	}
+
+	n.bound += uint64(val)
+	return true
+}
+
+// feasible checks that the node has at least one tour
+func (n *node) feasible() bool {
+	return n.bound != math.MaxUint64
+}
+
+// branchEdge selects the zero element with the maximum penalty. Penalty
+// is the increase of the bound if the edge is excluded: the smallest other
+// element of the row plus the smallest other element of the column.
+func (n *node) branchEdge() (int, int, uint64) {
+	size := n.size
+	bestRow, bestCol := noNode, noNode
+	var bestPenalty uint64
+
+	for i := 0; i < size; i++ {
+		if n.next[i] != noNode {
+			continue
+		}
+
+		row := n.matrix[i*size : (i+1)*size]
+		for j, val := range row {
+			if (val != 0) || (n.prev[j] != noNode) {
+				continue
+			}
+
+			penalty := uint64(n.rowMinExcept(i, j)) + uint64(n.colMinExcept(j, i))
+			if (bestRow == noNode) || (penalty > bestPenalty) {
+				bestRow, bestCol, bestPenalty = i, j, penalty
+			}
+		}
+	}
+
+	return bestRow, bestCol, bestPenalty
+}
+
+// rowMinExcept returns the minimum of active elements of the row,
+// skipping the given column
+func (n *node) rowMinExcept(i, except int) types.Distance {
+	min := types.Distance(inf)
+	for j, val := range n.matrix[i*n.size : (i+1)*n.size] {
+		if (j != except) && (n.prev[j] == noNode) && (val < min) {
+			min = val
+		}
+	}
+
+	return min
+}
+
+// colMinExcept returns the minimum of active elements of the column,
+// skipping the given row
+func (n *node) colMinExcept(j, except int) types.Distance {
+	min := types.Distance(inf)
+	for i := 0; i < n.size; i++ {
+		if val := n.matrix[i*n.size+j]; (i != except) && (n.next[i] == noNode) && (val < min) {
+			min = val
+		}
+	}
+
+	return min
+}
+
+// include creates a child with the edge included. The edge closing the
+// chain it belongs to into a subtour is forbidden.
+func (n *node) include(i, j int) *node {
+	c := n.copy()
+	c.next[i] = int16(j)
+	c.prev[j] = int16(i)
+	c.included++
+
+	start, end := i, j
+	for c.prev[start] != noNode {
+		start = int(c.prev[start])
+	}
+	for c.next[end] != noNode {
+		end = int(c.next[end])
+	}
+
+	if c.complete() {
+		// The last edge closing the tour is forced, unless it was excluded
+		if c.matrix[end*c.size+start] == inf {
+			c.bound = math.MaxUint64
+		}
+		return c
+	}
+	c.matrix[end*c.size+start] = inf
+
+	c.reduce()
+	return c
+}
+
+// exclude creates a child with the edge forbidden
+func (n *node) exclude(i, j int) *node {
+	c := n.copy()
+	c.matrix[i*c.size+j] = inf
+	c.reduce()
+
+	return c
+}
+
+// complete checks if all edges of a tour are known
+func (n *node) complete() bool {
+	return n.included >= n.size-1
+}
+
+// tour returns the complete tour starting and ending with the root node
+// and its distance in the original matrix
+func (n *node) tour(m [][]types.Distance) ([]types.Index, uint64) {
+	// The last edge closes the chain of included edges
+	start, end := 0, 0
+	for n.prev[start] != noNode {
+		start = int(n.prev[start])
+	}
+	for n.next[end] != noNode {
+		end = int(n.next[end])
+	}
+
+	path := make([]types.Index, 0, n.size+1)
+	var distance uint64
+	curr := 0
+	for k := 0; k < n.size; k++ {
+		next := int(n.next[curr])
+		if curr == end {
+			next = start
+		}
+		path = append(path, types.Index(curr))
+		distance += uint64(m[curr][next])
+		curr = next
+	}
+	path = append(path, 0)
+
+	return path, distance
+}
+
+// nodeHeap is a binary min heap of nodes by bounds, required for
+// heap.interface. Deeper nodes go first on equal bounds to reach
+// complete tours sooner.
+type nodeHeap []*node
+
+func (h nodeHeap) Len() int { return len(h) }
+
+func (h nodeHeap) Less(i, j int) bool {
+	if h[i].bound != h[j].bound {
+		return h[i].bound < h[j].bound
+	}
+
+	return h[i].included > h[j].included
+}
+
+func (h nodeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *nodeHeap) Push(x interface{}) { *h = append(*h, x.(*node)) }
+
+func (h *nodeHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return item
+}
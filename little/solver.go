@@ -0,0 +1,180 @@
+// Package little implements the branch and bound algorithm of Little, Murty,
+// Sweeney and Karel. Unlike other solvers, which extend the path node by
+// node, it branches on edges: a subtree either includes the zero edge of the
+// reduced matrix with the maximum penalty, or excludes it. Subtours are
+// eliminated by forbidding the edge, which would close a chain of included
+// edges too early. Penalties make exclusion subtrees expensive, so it prunes
+// well, especially on asymmetric matrices.
+package little
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/metrics"
+	"github.com/Spi1y/tsp-solver/solver2/stats"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Solver is a TSP solver object. It is used to set a distance matrix and start
+// calculations
+type Solver struct {
+	// Optional metrics collection, solves are not reported if it is nil
+	Metrics *metrics.Metrics
+
+	// Distance matrix
+	matrix [][]types.Distance
+	// Open nodes of the search tree
+	queue nodeHeap
+
+	// Current best solution
+	bestSolution         []types.Index
+	bestSolutionDistance uint64
+	found                bool
+
+	// Search telemetry
+	stats stats.Stats
+	start time.Time
+}
+
+// Solve solves the TSP problem with a given distance matrix.
+// Along with the solution it returns the search statistics.
+func (s *Solver) Solve(m [][]types.Distance) ([]types.Index, types.Distance, stats.Stats, error) {
+	return s.SolveContext(context.Background(), m)
+}
+
+// SolveContext is the same as Solve, but the search can be interrupted with
+// the context. In that case the best solution found so far is returned along
+// with the context error.
+func (s *Solver) SolveContext(ctx context.Context, m [][]types.Distance) ([]types.Index, types.Distance, stats.Stats, error) {
+	err := s.init(m)
+	if err != nil {
+		return nil, 0, stats.Stats{}, err
+	}
+
+	if len(m) == 1 {
+		// Root node is the only node of the tour
+		s.newSolutionFound([]types.Index{0, 0}, 0)
+		s.finish(nil)
+		return s.bestSolution, 0, s.stats, nil
+	}
+
+	s.insert(newRoot(s.matrix))
+
+	return s.search(ctx)
+}
+
+// init validates the distance matrix and resets the solver state
+func (s *Solver) init(m [][]types.Distance) error {
+	size := len(m)
+
+	if size == 0 {
+		return errors.New("Distance matrix is empty")
+	}
+	if size > math.MaxUint8+1 {
+		return errors.New("Distance matrix is too large")
+	}
+
+	// Matrix is copied to one backing array for better memory locality
+	fm, err := matrix.FromRows(m, math.MaxUint32)
+	if err != nil {
+		return err
+	}
+
+	s.start = time.Now()
+	s.stats = stats.Stats{}
+	s.matrix = fm.Rows()
+	s.queue = s.queue[:0]
+	s.bestSolution = []types.Index{}
+	s.bestSolutionDistance = 0
+	s.found = false
+
+	return nil
+}
+
+// search processes nodes from the queue until there are no nodes, which
+// can improve the best solution
+func (s *Solver) search(ctx context.Context) ([]types.Index, types.Distance, stats.Stats, error) {
+	done := ctx.Done()
+	for len(s.queue) > 0 {
+		select {
+		case <-done:
+			s.finish(ctx.Err())
+			return s.bestSolution, types.Distance(s.bestSolutionDistance), s.stats, ctx.Err()
+		default:
+		}
+
+		if s.found && (s.queue[0].bound >= s.bestSolutionDistance) {
+			// Other nodes are even worse
+			break
+		}
+
+		n := heap.Pop(&s.queue).(*node)
+		s.stats.TasksExpanded++
+
+		i, j, _ := n.branchEdge()
+		s.insert(n.include(i, j))
+		s.insert(n.exclude(i, j))
+	}
+
+	s.finish(nil)
+
+	return s.bestSolution, types.Distance(s.bestSolutionDistance), s.stats, nil
+}
+
+// insert inserts the node into the queue, skipping it if it can not improve
+// the best solution. Complete tours are processed right away.
+func (s *Solver) insert(n *node) {
+	s.stats.TasksCreated++
+
+	if !n.feasible() || (s.found && (n.bound >= s.bestSolutionDistance)) {
+		s.stats.PrunedByBound++
+		return
+	}
+
+	if n.complete() {
+		s.stats.TasksExpanded++
+		s.newSolutionFound(n.tour(s.matrix))
+		return
+	}
+
+	heap.Push(&s.queue, n)
+	s.stats.UpdateQueueLen(len(s.queue))
+}
+
+// finish fills the statistics which are calculated at the end of the search
+// and reports the solve to metrics
+func (s *Solver) finish(err error) {
+	s.stats.LowerBound = types.Distance(s.bestSolutionDistance)
+	if (err != nil) && (len(s.queue) > 0) && (!s.found || (s.queue[0].bound < s.bestSolutionDistance)) {
+		// Search was interrupted, lowest bound of remaining nodes is the bound
+		s.stats.LowerBound = types.Distance(s.queue[0].bound)
+	}
+
+	if err != nil {
+		s.stats.Open = len(s.queue)
+	} else {
+		s.stats.PrunedByTrim = len(s.queue)
+	}
+	s.stats.Duration = time.Since(s.start)
+
+	s.Metrics.ObserveSolve("little", len(s.matrix), types.Distance(s.bestSolutionDistance), s.stats, err)
+}
+
+func (s *Solver) newSolutionFound(path []types.Index, distance uint64) {
+	if s.found && (distance >= s.bestSolutionDistance) {
+		return
+	}
+
+	s.bestSolution = path
+	s.bestSolutionDistance = distance
+	s.found = true
+	s.stats.Incumbents = append(s.stats.Incumbents, stats.Incumbent{
+		Distance: types.Distance(distance),
+		Elapsed:  time.Since(s.start),
+	})
+}
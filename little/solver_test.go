@@ -0,0 +1,222 @@
+package little
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/gen"
+	"github.com/Spi1y/tsp-solver/metrics"
+	"github.com/Spi1y/tsp-solver/solver2"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/Spi1y/tsp-solver/tour"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolverSolve(t *testing.T) {
+	tests := solverTestCases()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Solver{}
+			path, dist, _, err := s.Solve(tt.distanceMatrix)
+
+			assert.Equal(t, tt.path, path)
+			assert.Equal(t, tt.dist, dist)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSolverSolveSmall(t *testing.T) {
+	s := &Solver{}
+
+	path, dist, _, err := s.Solve([][]types.Distance{{0}})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Index{0, 0}, path)
+	assert.Zero(t, dist)
+
+	path, dist, _, err = s.Solve([][]types.Distance{{0, 3}, {4, 0}})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Index{0, 1, 0}, path)
+	assert.Equal(t, types.Distance(7), dist)
+
+	_, _, _, err = s.Solve(nil)
+	assert.Error(t, err)
+	_, _, _, err = s.Solve([][]types.Distance{{0, 1}, {1}})
+	assert.Error(t, err)
+}
+
+func TestSolverSolveStats(t *testing.T) {
+	tests := solverTestCases()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Solver{}
+			_, dist, st, err := s.Solve(tt.distanceMatrix)
+			assert.NoError(t, err)
+
+			// Every created node is either expanded or pruned
+			assert.Equal(t, st.TasksCreated, st.TasksExpanded+st.PrunedByBound+st.PrunedByTrim+st.Open)
+			assert.NotZero(t, st.MaxQueueLen)
+			assert.Equal(t, dist, st.LowerBound)
+			if assert.NotEmpty(t, st.Incumbents) {
+				assert.Equal(t, dist, st.Incumbents[len(st.Incumbents)-1].Distance)
+			}
+		})
+	}
+}
+
+func TestSolverSolveCanceled(t *testing.T) {
+	tt := solveTestCase7Points()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := &Solver{Metrics: metrics.New()}
+	_, _, st, err := s.SolveContext(ctx, tt.distanceMatrix)
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.NotZero(t, st.Open)
+	assert.LessOrEqual(t, st.LowerBound, tt.dist)
+
+	var b strings.Builder
+	s.Metrics.WriteTo(&b)
+	assert.Contains(t, b.String(), `tsp_solve_cancellations_total{engine="little"} 1`)
+}
+
+func TestSolverSolveGenerated(t *testing.T) {
+	for _, kind := range gen.Kinds {
+		for _, size := range []int{3, 6, 9, 12} {
+			t.Run(fmt.Sprintf("%v-%d", kind, size), func(t *testing.T) {
+				m, err := gen.Generate(gen.Options{Kind: kind, Size: size, Seed: int64(size)})
+				assert.NoError(t, err)
+
+				s2 := &solver2.Solver{}
+				_, want, _, err := s2.Solve(m)
+				assert.NoError(t, err)
+
+				s := &Solver{}
+				path, dist, _, err := s.Solve(m)
+				assert.NoError(t, err)
+				assert.Equal(t, want, dist)
+
+				tr, err := tour.New(path)
+				if assert.NoError(t, err) {
+					assert.NoError(t, tr.Validate(m))
+					assert.Equal(t, dist, tr.Distance(m))
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkSolver(b *testing.B) {
+	for _, kind := range []gen.Kind{gen.Uniform, gen.Asymmetric} {
+		m, err := gen.Generate(gen.Options{Kind: kind, Size: 15, Seed: 1})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(fmt.Sprintf("little-%v", kind), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				s := &Solver{}
+				s.Solve(m)
+			}
+		})
+
+		b.Run(fmt.Sprintf("solver2-%v", kind), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				s := &solver2.Solver{}
+				s.Solve(m)
+			}
+		})
+	}
+}
+
+type solveTestCase struct {
+	name           string
+	distanceMatrix [][]types.Distance
+	path           []types.Index
+	dist           types.Distance
+	wantErr        bool
+}
+
+func solverTestCases() []*solveTestCase {
+	result := []*solveTestCase{}
+	result = append(result, solveTestCase2Points())
+	result = append(result, solveTestCase3Points())
+	result = append(result, solveTestCase4Points())
+	result = append(result, solveTestCase7Points())
+
+	return result
+}
+
+func solveTestCase2Points() *solveTestCase {
+	return &solveTestCase{
+		"Normal - 2 points",
+		[][]types.Distance{
+			{0, 1, 9},
+			{9, 0, 1},
+			{1, 9, 0},
+		},
+		[]types.Index{0, 1, 2, 0},
+		3,
+		false,
+	}
+}
+
+func solveTestCase3Points() *solveTestCase {
+	return &solveTestCase{
+		"Normal - 3 points",
+		[][]types.Distance{
+			{0, 1, 9, 9},
+			{9, 0, 9, 1},
+			{1, 9, 0, 9},
+			{9, 9, 1, 0},
+		},
+		[]types.Index{0, 1, 3, 2, 0},
+		4,
+		false,
+	}
+}
+
+func solveTestCase4Points() *solveTestCase {
+	return &solveTestCase{
+		"Real case - 4 points",
+		[][]types.Distance{
+			{0, 15_147, 4_596, 10_263, 5_482},
+			{17_465, 0, 19_314, 21_477, 20_619},
+			{4_643, 20_347, 0, 6_918, 1_340},
+			{10_506, 21_310, 7_257, 0, 6_089},
+			{6_585, 20_577, 1_340, 6_199, 0},
+		},
+		[]types.Index{0, 1, 3, 4, 2, 0},
+		48_696,
+		false,
+	}
+}
+
+func solveTestCase7Points() *solveTestCase {
+	return &solveTestCase{
+		"Real case - 7 points",
+		[][]types.Distance{
+			{0, 15147, 21742, 12730, 18594, 6147, 6955, 10000},
+			{17465, 0, 30524, 22534, 27376, 20763, 15326, 21214},
+			{23594, 43627, 0, 16165, 9604, 21957, 18560, 21180},
+			{11103, 22595, 16255, 0, 10210, 5909, 7880, 3274},
+			{19133, 27796, 9754, 10054, 0, 12856, 14099, 10486},
+			{6155, 21069, 23218, 7694, 14520, 0, 5419, 4964},
+			{5736, 14952, 18081, 8492, 14933, 6300, 0, 7172},
+			{10801, 21605, 17131, 4504, 11197, 3615, 6890, 0},
+		},
+		[]types.Index{0, 1, 6, 2, 4, 3, 7, 5, 0},
+		81_256,
+		false,
+	}
+}
@@ -1,15 +1,291 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
 	"time"
 
+	"github.com/Spi1y/tsp-solver/bench"
+	"github.com/Spi1y/tsp-solver/distributed"
+	"github.com/Spi1y/tsp-solver/gen"
 	"github.com/Spi1y/tsp-solver/solver"
 	"github.com/Spi1y/tsp-solver/solver/matrix"
 	"github.com/Spi1y/tsp-solver/solver/tasks"
+	"github.com/Spi1y/tsp-solver/solver2/tail"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/Spi1y/tsp-solver/verify"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "coordinator":
+			runCoordinator(os.Args[2:])
+			return
+		case "worker":
+			runWorker(os.Args[2:])
+			return
+		case "verify":
+			runVerify(os.Args[2:])
+			return
+		case "gen":
+			runGen(os.Args[2:])
+			return
+		case "bench":
+			runBench(os.Args[2:])
+			return
+		}
+	}
+
+	demo()
+}
+
+// runCoordinator solves the instance from the file with distributed workers
+func runCoordinator(args []string) {
+	fs := flag.NewFlagSet("coordinator", flag.ExitOnError)
+	network := fs.String("network", "tcp", "network to listen on, tcp or unix")
+	address := fs.String("addr", "127.0.0.1:7070", "address to listen on")
+	name := fs.String("f", "", "instance file, JSON or TSPLIB")
+	subtrees := fs.Int("subtrees", distributed.DefaultSubtrees, "number of subtrees the search is split into")
+	threshold := fs.Uint("threshold", 0, fmt.Sprintf("recursive threshold used by workers, %d selects it automatically", tail.ThresholdAuto))
+	fs.Parse(args)
+
+	if *threshold > uint(tail.ThresholdAuto) {
+		fmt.Fprintf(os.Stderr, "Coordinator error: threshold must be at most %d\n", tail.ThresholdAuto)
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	path, distance, err := coordinate(ctx, *network, *address, *name, distributed.Coordinator{
+		Subtrees:           *subtrees,
+		RecursiveThreshold: types.Index(*threshold),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Coordinator error: %v\n", err)
+		if len(path) == 0 {
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Best solution found so far:\n")
+	}
+
+	fmt.Printf("Path %v, distance %d\n", path, distance)
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func coordinate(ctx context.Context, network, address, name string, c distributed.Coordinator) ([]types.Index, types.Distance, error) {
+	if name == "" {
+		return nil, 0, errors.New("Instance file is not set")
+	}
+
+	inst, err := gen.ReadFile(name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return c.Solve(ctx, l, inst.Matrix)
+}
+
+// runWorker starts a distributed worker connected to the coordinator
+func runWorker(args []string) {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	network := fs.String("network", "tcp", "coordinator network, tcp or unix")
+	address := fs.String("addr", "127.0.0.1:7070", "coordinator address")
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := distributed.Work(ctx, *network, *address); err != nil {
+		fmt.Fprintf(os.Stderr, "Worker error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runVerify checks a solution certificate read from the file or stdin
+func runVerify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	name := fs.String("f", "-", "certificate JSON file, - for stdin")
+	fs.Parse(args)
+
+	r, err := verifyCertificate(*name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Verify error: %v\n", err)
+		os.Exit(1)
+	}
+
+	optimality := "not proven"
+	if r.Optimal {
+		optimality = "proven"
+	}
+	fmt.Printf("Tour is valid, distance %d, claimed optimal %v, optimality %s, gap %.4f\n", r.Distance, r.Claimed, optimality, r.Gap)
+	if r.Exact {
+		fmt.Printf("Exact optimum %d\n", r.Optimum)
+	}
+}
+
+func verifyCertificate(name string) (verify.Report, error) {
+	in := os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return verify.Report{}, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	c, err := verify.ReadCertificate(in)
+	if err != nil {
+		return verify.Report{}, err
+	}
+
+	return c.Check()
+}
+
+// runGen generates a random instance and writes it to the file or stdout
+func runGen(args []string) {
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	kind := fs.String("kind", gen.Euclidean.String(), "instance kind: uniform, euclidean, clustered, asymmetric, ties, grid or road")
+	size := fs.Int("size", 15, "number of nodes")
+	seed := fs.Int64("seed", 1, "random seed")
+	max := fs.Uint("max", gen.DefaultMaxDistance, "maximum distance")
+	skew := fs.Float64("skew", gen.DefaultSkew, "distance variation of asymmetric and road instances, from 0 to 1")
+	format := fs.String("format", "", "output format: json or tsplib, by default defined by the file extension")
+	out := fs.String("o", "-", "output file, - for stdout")
+	fs.Parse(args)
+
+	err := generate(*kind, gen.Options{
+		Size:        *size,
+		Seed:        *seed,
+		MaxDistance: types.Distance(*max),
+		Skew:        skew,
+	}, *format, *out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Gen error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func generate(kind string, o gen.Options, format string, out string) error {
+	var err error
+	o.Kind, err = gen.ParseKind(kind)
+	if err != nil {
+		return err
+	}
+
+	inst, err := gen.NewInstance(o)
+	if err != nil {
+		return err
+	}
+
+	f := gen.FormatOf(out)
+	switch format {
+	case "":
+	case "json":
+		f = gen.JSON
+	case "tsplib":
+		f = gen.TSPLIB
+	default:
+		return fmt.Errorf("Unknown format %q", format)
+	}
+
+	if out == "-" {
+		return inst.Write(os.Stdout, f)
+	}
+
+	w, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	err = inst.Write(w, f)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// runBench runs engines over a corpus of instance files and writes a report
+func runBench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	dir := fs.String("dir", ".", "directory with instance files")
+	engines := fs.String("engines", "", "comma separated engine names, all engines by default")
+	limit := fs.Duration("limit", time.Minute, "time limit of a single run")
+	format := fs.String("format", "csv", "report format: csv or json")
+	out := fs.String("o", "-", "report file, - for stdout")
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := benchmark(ctx, *dir, *engines, *limit, *format, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "Bench error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func benchmark(ctx context.Context, dir string, engines string, limit time.Duration, format string, out string) error {
+	write := bench.WriteCSV
+	switch format {
+	case "csv":
+	case "json":
+		write = bench.WriteJSON
+	default:
+		return fmt.Errorf("Unknown format %q", format)
+	}
+
+	r := &bench.Runner{Engines: bench.Engines(), TimeLimit: limit}
+	if engines != "" {
+		var err error
+		r.Engines, err = bench.FindEngines(strings.Split(engines, ","))
+		if err != nil {
+			return err
+		}
+	}
+
+	instances, err := bench.LoadCorpus(dir)
+	if err != nil {
+		return err
+	}
+
+	records, err := r.Run(ctx, instances)
+	if err != nil {
+		return err
+	}
+
+	if out == "-" {
+		return write(os.Stdout, records)
+	}
+
+	w, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	err = write(w, records)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func demo() {
 	case7 := matrix.ConvertToMatrix([][]int{
 		{-1, 5866, 13206, 12730, 4940, 10000, 15147, 5941},
 		{4780, -1, 8881, 7975, 7415, 5754, 17622, 1616},
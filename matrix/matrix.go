@@ -0,0 +1,188 @@
+// Package matrix implements a square matrix backed by one flat slice. It is
+// the common representation of distance matrices for all solvers.
+package matrix
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Number is a constraint of matrix element types
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// Matrix is a square matrix. Elements are stored row by row in one linear
+// backing array, so rows are contiguous and copying is cheap.
+// A special infinite value marks forbidden elements.
+type Matrix[T Number] struct {
+	size int
+	data []T
+	inf  T
+}
+
+// New creates a matrix of the given size filled with zeros.
+// The inf value is used for forbidden elements.
+func New[T Number](size int, inf T) *Matrix[T] {
+	return &Matrix[T]{
+		size: size,
+		data: make([]T, size*size),
+		inf:  inf,
+	}
+}
+
+// FromRows creates a matrix from a slice of rows, copying the data
+func FromRows[T Number](rows [][]T, inf T) (*Matrix[T], error) {
+	m := New(len(rows), inf)
+	for i, row := range rows {
+		if len(row) != m.size {
+			return nil, errors.New("Distance matrix is not square")
+		}
+		copy(m.Row(i), row)
+	}
+
+	return m, nil
+}
+
+// Size returns the number of rows (and columns)
+func (m *Matrix[T]) Size() int { return m.size }
+
+// Inf returns the value of forbidden elements
+func (m *Matrix[T]) Inf() T { return m.inf }
+
+// At returns the element of the i-th row and j-th column
+func (m *Matrix[T]) At(i, j int) T { return m.data[i*m.size+j] }
+
+// Set sets the element of the i-th row and j-th column
+func (m *Matrix[T]) Set(i, j int, val T) { m.data[i*m.size+j] = val }
+
+// Forbid marks the element as forbidden
+func (m *Matrix[T]) Forbid(i, j int) { m.Set(i, j, m.inf) }
+
+// Forbidden checks if the element is forbidden
+func (m *Matrix[T]) Forbidden(i, j int) bool { return m.At(i, j) == m.inf }
+
+// Data returns the backing array, row by row
+func (m *Matrix[T]) Data() []T { return m.data }
+
+// Row returns the i-th row. It is a view, changes are reflected in the matrix
+func (m *Matrix[T]) Row(i int) []T {
+	return m.data[i*m.size : (i+1)*m.size]
+}
+
+// Rows returns all rows as views. Rows are sliced from the backing array
+// with its full capacity, as solver.Matrix requires.
+func (m *Matrix[T]) Rows() [][]T {
+	rows := make([][]T, m.size)
+	for i := range rows {
+		rows[i] = m.data[i*m.size : (i+1)*m.size]
+	}
+
+	return rows
+}
+
+// Col returns the j-th column view
+func (m *Matrix[T]) Col(j int) Col[T] {
+	return Col[T]{m: m, j: j}
+}
+
+// Clone returns a copy of the matrix
+func (m *Matrix[T]) Clone() *Matrix[T] {
+	c := New(m.size, m.inf)
+	copy(c.data, m.data)
+
+	return c
+}
+
+// Sub extracts the submatrix of the given nodes in the given order
+func (m *Matrix[T]) Sub(nodes []int) (*Matrix[T], error) {
+	for _, node := range nodes {
+		if (node < 0) || (node >= m.size) {
+			return nil, fmt.Errorf("Node %d is out of range", node)
+		}
+	}
+
+	sub := New(len(nodes), m.inf)
+	for i, from := range nodes {
+		row := m.Row(from)
+		subRow := sub.Row(i)
+		for j, to := range nodes {
+			subRow[j] = row[to]
+		}
+	}
+
+	return sub, nil
+}
+
+// Permute returns the matrix with reordered nodes, the i-th node of the result
+// is the perm[i]-th node of the source
+func (m *Matrix[T]) Permute(perm []int) (*Matrix[T], error) {
+	if len(perm) != m.size {
+		return nil, errors.New("Permutation size mismatch")
+	}
+
+	seen := make([]bool, m.size)
+	for _, node := range perm {
+		if (node < 0) || (node >= m.size) || seen[node] {
+			return nil, errors.New("Invalid permutation")
+		}
+		seen[node] = true
+	}
+
+	return m.Sub(perm)
+}
+
+// Replace returns a copy of the matrix with forbidden elements set to the
+// given value, which becomes the new infinity
+func (m *Matrix[T]) Replace(inf T) *Matrix[T] {
+	c := New(m.size, inf)
+	for i, val := range m.data {
+		if val == m.inf {
+			val = inf
+		}
+		c.data[i] = val
+	}
+
+	return c
+}
+
+// Convert converts the matrix to another element type. Forbidden elements
+// are set to the given infinity.
+func Convert[U, T Number](m *Matrix[T], inf U) *Matrix[U] {
+	c := New(m.size, inf)
+	for i, val := range m.data {
+		if val == m.inf {
+			c.data[i] = inf
+			continue
+		}
+		c.data[i] = U(val)
+	}
+
+	return c
+}
+
+// Col is a view of the matrix column
+type Col[T Number] struct {
+	m *Matrix[T]
+	j int
+}
+
+// Len returns the column length
+func (c Col[T]) Len() int { return c.m.size }
+
+// At returns the i-th element of the column
+func (c Col[T]) At(i int) T { return c.m.At(i, c.j) }
+
+// Set sets the i-th element of the column
+func (c Col[T]) Set(i int, val T) { c.m.Set(i, c.j, val) }
+
+// AppendTo appends column elements to the slice and returns it
+func (c Col[T]) AppendTo(dst []T) []T {
+	for i := 0; i < c.m.size; i++ {
+		dst = append(dst, c.m.data[i*c.m.size+c.j])
+	}
+
+	return dst
+}
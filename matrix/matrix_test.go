@@ -0,0 +1,104 @@
+package matrix
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromRows(t *testing.T) {
+	m, err := FromRows([][]int{{1, 2}, {3, 4}}, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, m.Size())
+	assert.Equal(t, []int{1, 2, 3, 4}, m.Data())
+	assert.Equal(t, 3, m.At(1, 0))
+
+	_, err = FromRows([][]int{{1, 2}, {3}}, -1)
+	assert.Error(t, err)
+
+	empty, err := FromRows([][]uint32{}, math.MaxUint32)
+	assert.NoError(t, err)
+	assert.Zero(t, empty.Size())
+	assert.Empty(t, empty.Rows())
+}
+
+func TestMatrix_Views(t *testing.T) {
+	m, _ := FromRows([][]float64{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}, math.Inf(1))
+
+	// Rows are views of the same backing array
+	rows := m.Rows()
+	rows[1][2] = 60
+	assert.Equal(t, 60.0, m.At(1, 2))
+	m.Row(0)[0] = 10
+	assert.Equal(t, 10.0, rows[0][0])
+	assert.Equal(t, 9, cap(rows[0]))
+
+	col := m.Col(2)
+	assert.Equal(t, 3, col.Len())
+	assert.Equal(t, 60.0, col.At(1))
+	col.Set(0, 30)
+	assert.Equal(t, 30.0, m.At(0, 2))
+	assert.Equal(t, []float64{0, 30, 60, 9}, col.AppendTo([]float64{0}))
+}
+
+func TestMatrix_Forbid(t *testing.T) {
+	m := New[uint32](2, math.MaxUint32)
+	assert.False(t, m.Forbidden(0, 1))
+
+	m.Forbid(0, 1)
+	assert.True(t, m.Forbidden(0, 1))
+	assert.Equal(t, uint32(math.MaxUint32), m.At(0, 1))
+
+	r := m.Replace(1000)
+	assert.Equal(t, uint32(1000), r.Inf())
+	assert.True(t, r.Forbidden(0, 1))
+	assert.Equal(t, []uint32{0, 1000, 0, 0}, r.Data())
+	// Source is not changed
+	assert.True(t, m.Forbidden(0, 1))
+}
+
+func TestMatrix_Clone(t *testing.T) {
+	m, _ := FromRows([][]int{{1, 2}, {3, 4}}, -1)
+	c := m.Clone()
+	c.Set(0, 0, 42)
+
+	assert.Equal(t, 1, m.At(0, 0))
+	assert.Equal(t, 42, c.At(0, 0))
+	assert.Equal(t, -1, c.Inf())
+}
+
+func TestMatrix_Sub(t *testing.T) {
+	m, _ := FromRows([][]int{{0, 1, 2}, {3, 4, 5}, {6, 7, 8}}, -1)
+
+	sub, err := m.Sub([]int{2, 0})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int{{8, 6}, {2, 0}}, sub.Rows())
+
+	_, err = m.Sub([]int{0, 3})
+	assert.Error(t, err)
+}
+
+func TestMatrix_Permute(t *testing.T) {
+	m, _ := FromRows([][]int{{0, 1, 2}, {3, 4, 5}, {6, 7, 8}}, -1)
+
+	p, err := m.Permute([]int{1, 2, 0})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int{{4, 5, 3}, {7, 8, 6}, {1, 2, 0}}, p.Rows())
+
+	_, err = m.Permute([]int{1, 2})
+	assert.Error(t, err)
+	_, err = m.Permute([]int{1, 1, 0})
+	assert.Error(t, err)
+	_, err = m.Permute([]int{1, 2, 3})
+	assert.Error(t, err)
+}
+
+func TestConvert(t *testing.T) {
+	m, _ := FromRows([][]int{{-1, 5}, {7, -1}}, -1)
+
+	c := Convert(m, uint32(math.MaxUint32))
+	assert.Equal(t, [][]uint32{{math.MaxUint32, 5}, {7, math.MaxUint32}}, c.Rows())
+	assert.True(t, c.Forbidden(0, 0))
+	assert.False(t, c.Forbidden(0, 1))
+}
@@ -0,0 +1,251 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Spi1y/tsp-solver/solver2/stats"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+var (
+	durationBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300}
+	queueBuckets    = []float64{10, 100, 1_000, 10_000, 100_000, 1_000_000, 10_000_000}
+	gapBuckets      = []float64{0, 0.001, 0.01, 0.05, 0.1, 0.25, 0.5, 1}
+)
+
+// Metrics collects solver metrics and exposes them in the Prometheus text
+// format and via expvar. A nil *Metrics is valid: it records nothing and
+// exposes no metrics, so solvers do not pay for metrics when they are disabled.
+type Metrics struct {
+	mu sync.Mutex
+
+	solves        *family
+	cancellations *family
+	tasksExpanded *family
+	duration      *family
+	queueDepth    *family
+	gap           *family
+}
+
+// New creates and returns new metrics collection
+func New() *Metrics {
+	return &Metrics{
+		solves:        newCounter("tsp_solves_total", "Number of finished solves."),
+		cancellations: newCounter("tsp_solve_cancellations_total", "Number of solves interrupted before completion."),
+		tasksExpanded: newCounter("tsp_solve_tasks_expanded_total", "Number of tasks expanded by the search."),
+		duration:      newHistogram("tsp_solve_duration_seconds", "Duration of solves.", durationBuckets),
+		queueDepth:    newHistogram("tsp_solve_queue_depth_max", "Maximum tasks queue length during a solve.", queueBuckets),
+		gap:           newHistogram("tsp_solve_gap_ratio", "Relative gap between the solution and the lower bound at termination.", gapBuckets),
+	}
+}
+
+// ObserveSolve records results of a single solve made by the given engine.
+// It is called by solvers at the end of the search.
+func (m *Metrics) ObserveSolve(engine string, size int, distance types.Distance, st stats.Stats, err error) {
+	if m == nil {
+		return
+	}
+
+	engineLabel := labels("engine", engine)
+	sizeLabel := labels("engine", engine, "size", strconv.Itoa(size))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.solves.add(engineLabel, 1)
+	m.tasksExpanded.add(engineLabel, float64(st.TasksExpanded))
+	m.duration.observe(sizeLabel, st.Duration.Seconds())
+	m.queueDepth.observe(engineLabel, float64(st.MaxQueueLen))
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		m.cancellations.add(engineLabel, 1)
+	}
+
+	if distance != 0 && st.LowerBound <= distance {
+		m.gap.observe(engineLabel, float64(distance-st.LowerBound)/float64(distance))
+	}
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	if m == nil {
+		return 0, nil
+	}
+
+	var b strings.Builder
+
+	m.mu.Lock()
+	for _, f := range m.families() {
+		f.write(&b)
+	}
+	m.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Handler returns an HTTP handler serving metrics for the Prometheus scraper
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		m.WriteTo(w)
+	})
+}
+
+// Publish exposes metrics via expvar under the given name.
+// Like expvar.Publish, it panics if the name is already registered.
+func (m *Metrics) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		result := map[string]interface{}{}
+		if m == nil {
+			return result
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		for _, f := range m.families() {
+			result[f.name] = f.snapshot()
+		}
+		return result
+	}))
+}
+
+func (m *Metrics) families() []*family {
+	return []*family{m.solves, m.cancellations, m.tasksExpanded, m.duration, m.queueDepth, m.gap}
+}
+
+// labels renders label pairs in the Prometheus format
+func labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf("%s=%q", pairs[i], pairs[i+1]))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// family is a set of metrics with the same name and different labels
+type family struct {
+	name    string
+	help    string
+	buckets []float64
+
+	counters   map[string]float64
+	histograms map[string]*histogram
+}
+
+type histogram struct {
+	// Non-cumulative counts, the last one is for +Inf bucket
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newCounter(name, help string) *family {
+	return &family{
+		name:     name,
+		help:     help,
+		counters: map[string]float64{},
+	}
+}
+
+func newHistogram(name, help string, buckets []float64) *family {
+	return &family{
+		name:       name,
+		help:       help,
+		buckets:    buckets,
+		histograms: map[string]*histogram{},
+	}
+}
+
+func (f *family) add(labels string, value float64) {
+	f.counters[labels] += value
+}
+
+func (f *family) observe(labels string, value float64) {
+	h, ok := f.histograms[labels]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(f.buckets)+1)}
+		f.histograms[labels] = h
+	}
+
+	i := sort.SearchFloat64s(f.buckets, value)
+	h.counts[i]++
+	h.sum += value
+	h.count++
+}
+
+func (f *family) write(b *strings.Builder) {
+	fmt.Fprintf(b, "# HELP %s %s\n", f.name, f.help)
+
+	if f.histograms == nil {
+		fmt.Fprintf(b, "# TYPE %s counter\n", f.name)
+		for _, key := range sortedKeys(f.counters) {
+			fmt.Fprintf(b, "%s{%s} %s\n", f.name, key, formatFloat(f.counters[key]))
+		}
+		return
+	}
+
+	fmt.Fprintf(b, "# TYPE %s histogram\n", f.name)
+	for _, key := range sortedKeys(f.histograms) {
+		h := f.histograms[key]
+
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", f.name, key, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", f.name, key, h.count)
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", f.name, key, formatFloat(h.sum))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", f.name, key, h.count)
+	}
+}
+
+func (f *family) snapshot() map[string]interface{} {
+	result := map[string]interface{}{}
+
+	for key, value := range f.counters {
+		result[key] = value
+	}
+
+	for key, h := range f.histograms {
+		result[key] = map[string]interface{}{
+			"count": h.count,
+			"sum":   h.sum,
+		}
+	}
+
+	return result
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+
+	switch v := m.(type) {
+	case map[string]float64:
+		for key := range v {
+			keys = append(keys, key)
+		}
+	case map[string]*histogram:
+		for key := range v {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
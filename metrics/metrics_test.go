@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"context"
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Spi1y/tsp-solver/solver2/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_NilIsNoop(t *testing.T) {
+	var m *Metrics
+	assert.NotPanics(t, func() {
+		m.ObserveSolve("solver2", 5, 10, stats.Stats{}, nil)
+	})
+
+	var b strings.Builder
+	n, err := m.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Empty(t, b.String())
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Empty(t, rec.Body.String())
+
+	m.Publish("tsp_test_nil")
+	assert.Equal(t, "{}", expvar.Get("tsp_test_nil").String())
+}
+
+func TestMetrics_WriteTo(t *testing.T) {
+	m := New()
+	m.ObserveSolve("solver2", 5, 100, stats.Stats{
+		TasksExpanded: 12,
+		MaxQueueLen:   40,
+		LowerBound:    100,
+		Duration:      20 * time.Millisecond,
+	}, nil)
+	m.ObserveSolve("solver3", 5, 100, stats.Stats{
+		TasksExpanded: 3,
+		MaxQueueLen:   4,
+		LowerBound:    80,
+		Duration:      2 * time.Second,
+	}, context.Canceled)
+
+	var b strings.Builder
+	_, err := m.WriteTo(&b)
+	assert.NoError(t, err)
+	out := b.String()
+
+	expected := []string{
+		"# TYPE tsp_solves_total counter",
+		`tsp_solves_total{engine="solver2"} 1`,
+		`tsp_solve_tasks_expanded_total{engine="solver2"} 12`,
+		`tsp_solve_cancellations_total{engine="solver3"} 1`,
+		"# TYPE tsp_solve_duration_seconds histogram",
+		`tsp_solve_duration_seconds_bucket{engine="solver2",size="5",le="0.01"} 0`,
+		`tsp_solve_duration_seconds_bucket{engine="solver2",size="5",le="0.05"} 1`,
+		`tsp_solve_duration_seconds_bucket{engine="solver2",size="5",le="+Inf"} 1`,
+		`tsp_solve_duration_seconds_count{engine="solver3",size="5"} 1`,
+		`tsp_solve_queue_depth_max_bucket{engine="solver2",le="100"} 1`,
+		`tsp_solve_gap_ratio_bucket{engine="solver2",le="0"} 1`,
+		`tsp_solve_gap_ratio_bucket{engine="solver3",le="0.1"} 0`,
+		`tsp_solve_gap_ratio_bucket{engine="solver3",le="0.25"} 1`,
+		`tsp_solve_gap_ratio_sum{engine="solver3"} 0.2`,
+	}
+	for _, line := range expected {
+		assert.Contains(t, out, line+"\n")
+	}
+	assert.NotContains(t, out, `tsp_solve_cancellations_total{engine="solver2"}`)
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.ObserveSolve("solver2", 3, 10, stats.Stats{LowerBound: 10}, nil)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, "text/plain; version=0.0.4", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `tsp_solves_total{engine="solver2"} 1`)
+}
+
+func TestMetrics_Publish(t *testing.T) {
+	m := New()
+	m.ObserveSolve("solver2", 3, 10, stats.Stats{TasksExpanded: 4, LowerBound: 10}, nil)
+	m.Publish("tsp_test")
+
+	v := expvar.Get("tsp_test")
+	if assert.NotNil(t, v) {
+		assert.Contains(t, v.String(), `"tsp_solve_tasks_expanded_total":{"engine=\"solver2\"":4}`)
+	}
+}
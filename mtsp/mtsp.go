@@ -0,0 +1,106 @@
+// Package mtsp solves the multiple TSP with a shared depot: several vehicles
+// start at node 0, visit every other node exactly once between them and
+// return back. The problem is reduced to the TSP (see Transform) and solved
+// with solver2. The total length is the tour length of the reduced problem,
+// and the maximum route length is minimized by solver2 with depots of the
+// reduced matrix splitting the tour into routes (see solver2.Solver.Depots).
+package mtsp
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Objective is a measure of the solution quality
+type Objective int
+
+const (
+	// MinTotal minimizes the sum of route lengths
+	MinTotal Objective = iota
+	// MinMax minimizes the length of the longest route
+	MinMax
+)
+
+// Options of the solve
+type Options struct {
+	// Number of vehicles, at least one
+	Vehicles  int
+	Objective Objective
+	// AllowIdle lets vehicles stay at the depot. Otherwise every vehicle
+	// visits at least one node
+	AllowIdle bool
+	// Optional configured solver for the TSP, a default one is used if nil.
+	// With MinMax its configuration is copied with Depots set.
+	Solver *solver2.Solver
+}
+
+// Solution is a set of routes, one per vehicle
+type Solution struct {
+	// Routes start and end with the depot
+	Routes [][]types.Index
+	// Lengths of the routes
+	Lengths []types.Distance
+	// Sum and maximum of route lengths
+	Total types.Distance
+	Max   types.Distance
+}
+
+// ErrNoSolution is returned when nodes can not be covered by routes, for
+// example if there are more vehicles than nodes and they can not be idle
+var ErrNoSolution = errors.New("No routes cover all nodes")
+
+// Solve finds routes of the vehicles for the distance matrix. If the search
+// is interrupted with the context, the best solution found so far is
+// returned along with the context error.
+func Solve(ctx context.Context, m *matrix.Matrix[types.Distance], o Options) (*Solution, error) {
+	t, err := Transform(m, o.Vehicles, o.AllowIdle)
+	if err != nil {
+		return nil, err
+	}
+
+	s := o.Solver
+	if s == nil {
+		s = &solver2.Solver{}
+	}
+	if o.Objective == MinMax {
+		// The caller's solver keeps minimizing the tour length
+		config := *s
+		config.Depots = depots(t.Size(), m.Size())
+		s = &config
+	}
+
+	tour, _, _, err := s.SolveMatrix(ctx, t)
+	if errors.Is(err, solver2.ErrNoTour) {
+		return nil, ErrNoSolution
+	}
+	if len(tour) == 0 {
+		return nil, err
+	}
+
+	return newSolution(m, Split(tour, m.Size())), err
+}
+
+// newSolution calculates lengths of the routes
+func newSolution(m *matrix.Matrix[types.Distance], routes [][]types.Index) *Solution {
+	sol := &Solution{
+		Routes:  routes,
+		Lengths: make([]types.Distance, len(routes)),
+	}
+
+	for r, route := range routes {
+		for i := 1; i < len(route); i++ {
+			sol.Lengths[r] += m.At(int(route[i-1]), int(route[i]))
+		}
+
+		sol.Total += sol.Lengths[r]
+		if sol.Max < sol.Lengths[r] {
+			sol.Max = sol.Lengths[r]
+		}
+	}
+
+	return sol
+}
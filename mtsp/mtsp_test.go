@@ -0,0 +1,186 @@
+package mtsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/gen"
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransform(t *testing.T) {
+	m, err := matrix.FromRows([][]types.Distance{
+		{0, 1, 2},
+		{3, 0, 4},
+		{5, 6, 0},
+	}, 100)
+	assert.NoError(t, err)
+
+	tm, err := Transform(m, 2, false)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]types.Distance{
+		{0, 1, 2, 100},
+		{3, 0, 4, 3},
+		{5, 6, 0, 5},
+		{100, 1, 2, 0},
+	}, tm.Rows())
+
+	tm, err = Transform(m, 2, true)
+	assert.NoError(t, err)
+	assert.Equal(t, types.Distance(0), tm.At(0, 3))
+	assert.Equal(t, types.Distance(0), tm.At(3, 0))
+
+	_, err = Transform(m, 0, false)
+	assert.Error(t, err)
+	_, err = Transform(matrix.New[types.Distance](0, 100), 1, false)
+	assert.Error(t, err)
+}
+
+func TestSplit(t *testing.T) {
+	assert.Equal(t, [][]types.Index{{0, 1, 2, 0}, {0, 3, 0}},
+		Split([]types.Index{0, 1, 2, 4, 3, 0}, 4))
+	assert.Equal(t, [][]types.Index{{0, 0}, {0, 1, 0}, {0, 0}},
+		Split([]types.Index{0, 5, 1, 4, 0}, 4))
+	assert.Equal(t, [][]types.Index{{0, 0}}, Split([]types.Index{0, 0}, 1))
+}
+
+func TestSolve(t *testing.T) {
+	for _, kind := range []gen.Kind{gen.Uniform, gen.Euclidean, gen.Asymmetric} {
+		for size := 2; size <= 6; size++ {
+			for vehicles := 1; vehicles <= 3; vehicles++ {
+				for _, allowIdle := range []bool{false, true} {
+					name := fmt.Sprintf("%v/%d/%d/%v", kind, size, vehicles, allowIdle)
+					t.Run(name, func(t *testing.T) {
+						rows, err := gen.Generate(gen.Options{Kind: kind, Size: size, Seed: int64(size * vehicles), MaxDistance: 100})
+						assert.NoError(t, err)
+						m, err := matrix.FromRows(rows, 1_000_000)
+						assert.NoError(t, err)
+
+						wantTotal, wantMax, feasible := bruteForce(m, vehicles, allowIdle)
+						for _, objective := range []Objective{MinTotal, MinMax} {
+							sol, err := Solve(context.Background(), m, Options{Vehicles: vehicles, Objective: objective, AllowIdle: allowIdle})
+							if !feasible {
+								assert.True(t, errors.Is(err, ErrNoSolution), "%v", err)
+								continue
+							}
+							if !assert.NoError(t, err) {
+								continue
+							}
+
+							assertRoutes(t, m, sol, vehicles, allowIdle)
+							if objective == MinTotal {
+								assert.Equal(t, wantTotal, sol.Total)
+							} else {
+								assert.Equal(t, wantMax, sol.Max)
+							}
+						}
+					})
+				}
+			}
+		}
+	}
+}
+
+func TestSolveCanceled(t *testing.T) {
+	rows, err := gen.Generate(gen.Options{Kind: gen.Uniform, Size: 9, Seed: 1})
+	assert.NoError(t, err)
+	m, err := matrix.FromRows(rows, 1_000_000)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Solve(ctx, m, Options{Vehicles: 2, Objective: MinMax})
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestSolveMinMaxLarge(t *testing.T) {
+	// Larger than the brute force can check
+	rows, err := gen.Generate(gen.Options{Kind: gen.Uniform, Size: 16, Seed: 2})
+	assert.NoError(t, err)
+	m, err := matrix.FromRows(rows, 1_000_000)
+	assert.NoError(t, err)
+
+	total, err := Solve(context.Background(), m, Options{Vehicles: 3})
+	assert.NoError(t, err)
+
+	// Routes of the shortest total length are a feasible min-max solution
+	sol, err := Solve(context.Background(), m, Options{Vehicles: 3, Objective: MinMax})
+	assert.NoError(t, err)
+	assertRoutes(t, m, sol, 3, false)
+	assert.LessOrEqual(t, sol.Max, total.Max)
+	assert.GreaterOrEqual(t, sol.Total, total.Total)
+}
+
+// assertRoutes checks that routes start and end with the depot and visit
+// every node once
+func assertRoutes(t *testing.T, m *matrix.Matrix[types.Distance], sol *Solution, vehicles int, allowIdle bool) {
+	assert.Len(t, sol.Routes, vehicles)
+	seen := make([]int, m.Size())
+	for _, route := range sol.Routes {
+		assert.Equal(t, types.Index(0), route[0])
+		assert.Equal(t, types.Index(0), route[len(route)-1])
+		if !allowIdle {
+			assert.Greater(t, len(route), 2)
+		}
+		for _, node := range route[1 : len(route)-1] {
+			seen[node]++
+		}
+	}
+	for node := 1; node < m.Size(); node++ {
+		assert.Equal(t, 1, seen[node], "node %d", node)
+	}
+}
+
+// bruteForce enumerates all tours of the transformed matrix
+func bruteForce(m *matrix.Matrix[types.Distance], vehicles int, allowIdle bool) (types.Distance, types.Distance, bool) {
+	tm, _ := Transform(m, vehicles, allowIdle)
+	size := tm.Size()
+
+	var bestTotal, bestMax types.Distance
+	found := false
+
+	perm := make([]types.Index, 0, size+1)
+	perm = append(perm, 0)
+	used := make([]bool, size)
+	used[0] = true
+
+	var visit func()
+	visit = func() {
+		if len(perm) == size {
+			tour := append(append([]types.Index{}, perm...), 0)
+			for i := 1; i < len(tour); i++ {
+				if tm.Forbidden(int(tour[i-1]), int(tour[i])) {
+					return
+				}
+			}
+
+			sol := newSolution(m, Split(tour, m.Size()))
+			if !found || (sol.Total < bestTotal) {
+				bestTotal = sol.Total
+			}
+			if !found || (sol.Max < bestMax) {
+				bestMax = sol.Max
+			}
+			found = true
+			return
+		}
+
+		for next := 1; next < size; next++ {
+			if used[next] {
+				continue
+			}
+			used[next] = true
+			perm = append(perm, types.Index(next))
+			visit()
+			perm = perm[:len(perm)-1]
+			used[next] = false
+		}
+	}
+	visit()
+
+	return bestTotal, bestMax, found
+}
@@ -0,0 +1,93 @@
+package mtsp
+
+import (
+	"errors"
+	"math"
+
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Transform reduces the mTSP to the TSP by duplicating the depot. Copies of
+// the depot get indices after the original nodes and the same distances to
+// and from other nodes. A TSP tour visits all copies, and the parts of the
+// tour between them are routes of vehicles. Edges between copies correspond
+// to idle vehicles, they are free if allowIdle is set and forbidden otherwise.
+func Transform(m *matrix.Matrix[types.Distance], vehicles int, allowIdle bool) (*matrix.Matrix[types.Distance], error) {
+	if vehicles < 1 {
+		return nil, errors.New("At least one vehicle is required")
+	}
+
+	size := m.Size()
+	if size == 0 {
+		return nil, errors.New("Distance matrix is empty")
+	}
+
+	total := size + vehicles - 1
+	if total > math.MaxUint8+1 {
+		return nil, errors.New("Too many nodes and vehicles")
+	}
+
+	t := matrix.New(total, m.Inf())
+	for i := 0; i < total; i++ {
+		for j := 0; j < total; j++ {
+			src, dst := original(i, size), original(j, size)
+
+			switch {
+			case i == j:
+				t.Set(i, j, 0)
+			case (src == 0) && (dst == 0):
+				// Idle vehicle
+				if allowIdle {
+					t.Set(i, j, 0)
+				} else {
+					t.Forbid(i, j)
+				}
+			default:
+				t.Set(i, j, m.At(src, dst))
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// Split splits a tour of the transformed matrix into routes. Every route
+// starts and ends with the depot, routes of idle vehicles are [0, 0].
+func Split(tour []types.Index, size int) [][]types.Index {
+	var routes [][]types.Index
+	var route []types.Index
+
+	for _, node := range tour {
+		if original(int(node), size) != 0 {
+			route = append(route, node)
+			continue
+		}
+
+		if route != nil {
+			routes = append(routes, append(route, 0))
+		}
+		route = []types.Index{0}
+	}
+
+	return routes
+}
+
+// depots marks copies of the depot in the transformed matrix
+func depots(total int, size int) []bool {
+	marks := make([]bool, total)
+	for node := range marks {
+		marks[node] = original(node, size) == 0
+	}
+
+	return marks
+}
+
+// original maps a node of the transformed matrix to the original one
+func original(node int, size int) int {
+	if node >= size {
+		return 0
+	}
+
+	return node
+}
@@ -0,0 +1,135 @@
+package preprocess
+
+import (
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Violation is a triple of nodes breaking the triangle inequality:
+// going from one node to another via the third one is shorter than directly
+type Violation struct {
+	From, Via, To int
+	Direct        types.Distance
+	Detour        types.Distance
+}
+
+// Violations finds all triangle inequality violations of the matrix.
+// Forbidden edges are ignored.
+func Violations(m *matrix.Matrix[types.Distance]) []Violation {
+	var result []Violation
+
+	size := m.Size()
+	for i := 0; i < size; i++ {
+		for k := 0; k < size; k++ {
+			if (k == i) || m.Forbidden(i, k) {
+				continue
+			}
+			for j := 0; j < size; j++ {
+				if (j == i) || (j == k) || m.Forbidden(k, j) {
+					continue
+				}
+
+				detour := uint64(m.At(i, k)) + uint64(m.At(k, j))
+				if detour < uint64(m.At(i, j)) {
+					result = append(result, Violation{
+						From:   i,
+						Via:    k,
+						To:     j,
+						Direct: m.At(i, j),
+						Detour: types.Distance(detour),
+					})
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// Closure is a shortest path closure of the matrix. Its elements are the
+// shortest distances between nodes, so the triangle inequality holds.
+type Closure struct {
+	Matrix *matrix.Matrix[types.Distance]
+	// next[i*size+j] is the next node on the shortest path from i to j
+	next []int
+}
+
+// Close calculates the shortest path closure with the Floyd-Warshall
+// algorithm. Forbidden edges are treated as missing, nodes unreachable
+// from each other stay forbidden.
+func Close(m *matrix.Matrix[types.Distance]) *Closure {
+	size := m.Size()
+	c := &Closure{
+		Matrix: m.Clone(),
+		next:   make([]int, size*size),
+	}
+
+	for i := 0; i < size; i++ {
+		for j := 0; j < size; j++ {
+			c.next[i*size+j] = j
+		}
+	}
+
+	d := c.Matrix
+	for k := 0; k < size; k++ {
+		rowK := d.Row(k)
+		for i := 0; i < size; i++ {
+			if (i == k) || d.Forbidden(i, k) {
+				continue
+			}
+
+			rowI := d.Row(i)
+			toK := uint64(rowI[k])
+			for j := 0; j < size; j++ {
+				if (j == i) || (rowK[j] == d.Inf()) {
+					continue
+				}
+
+				// Detours not representable below the forbidden value
+				// are skipped, the edge keeps its current value
+				detour := toK + uint64(rowK[j])
+				if detour >= uint64(d.Inf()) {
+					continue
+				}
+
+				if (rowI[j] == d.Inf()) || (detour < uint64(rowI[j])) {
+					rowI[j] = types.Distance(detour)
+					c.next[i*size+j] = c.next[i*size+k]
+				}
+			}
+		}
+	}
+
+	return c
+}
+
+// Path returns original matrix legs of the shortest path between nodes,
+// including both of them
+func (c *Closure) Path(from, to int) []int {
+	size := c.Matrix.Size()
+	path := []int{from}
+	for from != to {
+		from = c.next[from*size+to]
+		path = append(path, from)
+	}
+
+	return path
+}
+
+// Expand replaces each leg of the tour with the corresponding shortest path
+// of the original matrix. The result is a closed walk of the same distance,
+// which may visit some nodes several times.
+func (c *Closure) Expand(tour []types.Index) []types.Index {
+	if len(tour) == 0 {
+		return []types.Index{}
+	}
+
+	walk := []types.Index{tour[0]}
+	for i := 1; i < len(tour); i++ {
+		for _, node := range c.Path(int(tour[i-1]), int(tour[i]))[1:] {
+			walk = append(walk, types.Index(node))
+		}
+	}
+
+	return walk
+}
@@ -0,0 +1,94 @@
+package preprocess
+
+import (
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Reduction is a matrix with co-located nodes merged
+type Reduction struct {
+	Matrix *matrix.Matrix[types.Distance]
+	// Groups[i] lists original nodes merged into the i-th reduced node,
+	// the first one is a representative
+	Groups [][]int
+}
+
+// MergeDuplicates merges nodes with zero distances in both directions between
+// them. Reduced nodes have distances of their representatives (the nodes with
+// lowest indexes), so the root node stays first. Merging is exact if the
+// triangle inequality holds (see Close).
+func MergeDuplicates(m *matrix.Matrix[types.Distance]) *Reduction {
+	size := m.Size()
+
+	// Union-find with the lowest index as a root
+	parent := make([]int, size)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := 0; i < size; i++ {
+		for j := i + 1; j < size; j++ {
+			if (m.At(i, j) != 0) || (m.At(j, i) != 0) {
+				continue
+			}
+
+			a, b := find(i), find(j)
+			if a > b {
+				a, b = b, a
+			}
+			parent[b] = a
+		}
+	}
+
+	r := &Reduction{}
+	index := make([]int, size)
+	for i := 0; i < size; i++ {
+		root := find(i)
+		if root == i {
+			index[i] = len(r.Groups)
+			r.Groups = append(r.Groups, []int{i})
+			continue
+		}
+		index[i] = index[root]
+		r.Groups[index[i]] = append(r.Groups[index[i]], i)
+	}
+
+	representatives := make([]int, len(r.Groups))
+	for i, group := range r.Groups {
+		representatives[i] = group[0]
+	}
+	// Representatives are always valid nodes
+	r.Matrix, _ = m.Sub(representatives)
+
+	return r
+}
+
+// Expand replaces reduced nodes of the tour with their groups
+func (r *Reduction) Expand(tour []types.Index) []types.Index {
+	if len(tour) == 0 {
+		return []types.Index{}
+	}
+
+	expanded := make([]types.Index, 0, len(tour))
+	for i, node := range tour {
+		group := r.Groups[node]
+		if (i == len(tour)-1) && (node == tour[0]) {
+			// Closing node returns to the start of the tour only
+			expanded = append(expanded, types.Index(group[0]))
+			break
+		}
+
+		for _, original := range group {
+			expanded = append(expanded, types.Index(original))
+		}
+	}
+
+	return expanded
+}
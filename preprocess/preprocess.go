@@ -0,0 +1,59 @@
+// Package preprocess transforms distance matrices before solving and maps
+// solutions of transformed matrices back onto the original ones
+package preprocess
+
+import (
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Options of preprocessing
+type Options struct {
+	// Replace distances with the shortest paths, fixing triangle
+	// inequality violations
+	Closure bool
+	// Merge co-located nodes
+	MergeDuplicates bool
+}
+
+// Plan is a preprocessed matrix along with the data to restore tours
+type Plan struct {
+	// Matrix to solve
+	Matrix *matrix.Matrix[types.Distance]
+
+	closure   *Closure
+	reduction *Reduction
+}
+
+// Prepare preprocesses the matrix. The closure is calculated first,
+// as merging is exact only when the triangle inequality holds.
+func Prepare(m *matrix.Matrix[types.Distance], o Options) *Plan {
+	p := &Plan{Matrix: m}
+
+	if o.Closure {
+		p.closure = Close(p.Matrix)
+		p.Matrix = p.closure.Matrix
+	}
+
+	if o.MergeDuplicates {
+		p.reduction = MergeDuplicates(p.Matrix)
+		p.Matrix = p.reduction.Matrix
+	}
+
+	return p
+}
+
+// Expand maps a tour of the preprocessed matrix onto the original one.
+// With the closure, the result is a closed walk, which may visit some nodes
+// several times. Its distance on the original matrix equals to the tour distance.
+func (p *Plan) Expand(tour []types.Index) []types.Index {
+	if p.reduction != nil {
+		tour = p.reduction.Expand(tour)
+	}
+
+	if p.closure != nil {
+		tour = p.closure.Expand(tour)
+	}
+
+	return tour
+}
@@ -0,0 +1,144 @@
+package preprocess
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const inf = math.MaxUint32
+
+func newMatrix(t *testing.T, rows [][]types.Distance) *matrix.Matrix[types.Distance] {
+	m, err := matrix.FromRows(rows, inf)
+	assert.NoError(t, err)
+	return m
+}
+
+func walkDistance(m *matrix.Matrix[types.Distance], walk []types.Index) types.Distance {
+	var d types.Distance
+	for i := 1; i < len(walk); i++ {
+		d += m.At(int(walk[i-1]), int(walk[i]))
+	}
+	return d
+}
+
+func TestViolations(t *testing.T) {
+	m := newMatrix(t, [][]types.Distance{
+		{0, 1, 10},
+		{1, 0, 2},
+		{10, 2, 0},
+	})
+
+	assert.Equal(t, []Violation{
+		{From: 0, Via: 1, To: 2, Direct: 10, Detour: 3},
+		{From: 2, Via: 1, To: 0, Direct: 10, Detour: 3},
+	}, Violations(m))
+
+	assert.Empty(t, Violations(Close(m).Matrix))
+}
+
+func TestClose(t *testing.T) {
+	m := newMatrix(t, [][]types.Distance{
+		{0, 1, inf, 10},
+		{inf, 0, 1, inf},
+		{inf, inf, 0, 1},
+		{1, inf, inf, 0},
+	})
+
+	c := Close(m)
+	assert.Equal(t, [][]types.Distance{
+		{0, 1, 2, 3},
+		{3, 0, 1, 2},
+		{2, 3, 0, 1},
+		{1, 2, 3, 0},
+	}, c.Matrix.Rows())
+	assert.Equal(t, []int{0, 1, 2, 3}, c.Path(0, 3))
+	assert.Equal(t, []int{2, 3, 0}, c.Path(2, 0))
+	assert.Equal(t, []int{1}, c.Path(1, 1))
+
+	// Original matrix is not modified
+	assert.Equal(t, types.Distance(10), m.At(0, 3))
+
+	walk := c.Expand([]types.Index{0, 3, 2, 1, 0})
+	assert.Equal(t, walk[0], types.Index(0))
+	assert.Equal(t, walk[len(walk)-1], types.Index(0))
+	assert.Equal(t, walkDistance(c.Matrix, []types.Index{0, 3, 2, 1, 0}), walkDistance(m, walk))
+	for i := 1; i < len(walk); i++ {
+		assert.False(t, m.Forbidden(int(walk[i-1]), int(walk[i])))
+	}
+
+	// Unreachable nodes stay forbidden
+	c = Close(newMatrix(t, [][]types.Distance{{0, inf}, {1, 0}}))
+	assert.True(t, c.Matrix.Forbidden(0, 1))
+
+	// Detours overflowing the distance type are not taken
+	c = Close(newMatrix(t, [][]types.Distance{
+		{0, inf - 1, inf},
+		{inf, 0, 2},
+		{1, inf, 0},
+	}))
+	assert.True(t, c.Matrix.Forbidden(0, 2))
+	assert.Equal(t, types.Distance(3), c.Matrix.At(1, 0))
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	m := newMatrix(t, [][]types.Distance{
+		{0, 3, 0, 5, 3},
+		{3, 0, 3, 4, 0},
+		{0, 3, 0, 5, 3},
+		{5, 4, 5, 0, 4},
+		{3, 0, 3, 4, 0},
+	})
+
+	r := MergeDuplicates(m)
+	assert.Equal(t, [][]int{{0, 2}, {1, 4}, {3}}, r.Groups)
+	assert.Equal(t, [][]types.Distance{
+		{0, 3, 5},
+		{3, 0, 4},
+		{5, 4, 0},
+	}, r.Matrix.Rows())
+
+	assert.Equal(t, []types.Index{0, 2, 3, 1, 4, 0}, r.Expand([]types.Index{0, 2, 1, 0}))
+	assert.Equal(t, []types.Index{}, r.Expand(nil))
+
+	// One-way zero distances are not merged
+	r = MergeDuplicates(newMatrix(t, [][]types.Distance{{0, 0}, {1, 0}}))
+	assert.Equal(t, [][]int{{0}, {1}}, r.Groups)
+}
+
+func TestPrepare(t *testing.T) {
+	// Nodes 1 and 3 are co-located, the direct edge 0-2 is a long one
+	m := newMatrix(t, [][]types.Distance{
+		{0, 2, 20, 2},
+		{2, 0, 3, 0},
+		{20, 3, 0, 3},
+		{2, 0, 3, 0},
+	})
+
+	for _, o := range []Options{{}, {Closure: true}, {MergeDuplicates: true}, {Closure: true, MergeDuplicates: true}} {
+		p := Prepare(m, o)
+
+		s := &solver2.Solver{}
+		path, distance, _, err := s.SolveMatrix(context.Background(), p.Matrix)
+		assert.NoError(t, err)
+
+		walk := p.Expand(path)
+		assert.Equal(t, types.Index(0), walk[0])
+		assert.Equal(t, types.Index(0), walk[len(walk)-1])
+		assert.Equal(t, distance, walkDistance(m, walk), "%+v", o)
+
+		visited := map[types.Index]bool{}
+		for _, node := range walk {
+			visited[node] = true
+		}
+		assert.Len(t, visited, m.Size())
+	}
+
+	p := Prepare(m, Options{Closure: true, MergeDuplicates: true})
+	assert.Equal(t, 3, p.Matrix.Size())
+}
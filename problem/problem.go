@@ -0,0 +1,205 @@
+// Package problem describes a TSP instance by node coordinates and builds
+// distance matrices for solvers from it.
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Spi1y/tsp-solver/solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/Spi1y/tsp-solver/tour"
+)
+
+// Mean Earth radius in kilometers, used by Haversine
+const EarthRadius = 6371.0
+
+// Node is a point of the problem
+type Node struct {
+	// Optional identifier of the node in the caller domain
+	ID string
+	// Optional human readable name
+	Label string
+	// Coordinates. For Haversine X is a longitude and Y is a latitude in degrees
+	X, Y float64
+}
+
+// DistanceFunc calculates the distance between two nodes
+type DistanceFunc func(a, b Node) float64
+
+// Euclidean is a planar straight line distance
+func Euclidean(a, b Node) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
+
+// Manhattan is a planar distance along the axes
+func Manhattan(a, b Node) float64 {
+	return math.Abs(a.X-b.X) + math.Abs(a.Y-b.Y)
+}
+
+// Haversine is a great-circle distance in kilometers between points
+// on the Earth surface
+func Haversine(a, b Node) float64 {
+	lat1 := a.Y * math.Pi / 180
+	lat2 := b.Y * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.X - a.X) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Rounding defines how fractional distances are converted to integers
+type Rounding int
+
+const (
+	// RoundNearest rounds to the nearest integer, halves away from zero
+	RoundNearest Rounding = iota
+	// RoundUp rounds up, which keeps the triangle inequality
+	RoundUp
+	// RoundDown truncates the fractional part
+	RoundDown
+)
+
+// Problem is a TSP instance given by node coordinates.
+// The first node is the starting one.
+type Problem struct {
+	Nodes []Node
+	// Distance function, Euclidean is used if it is nil
+	Distance DistanceFunc
+	// Distances are multiplied by the scale before rounding.
+	// Zero value means no scaling
+	Scale float64
+	// Rounding of scaled distances
+	Rounding Rounding
+}
+
+// distance calculates the rounded distance between nodes with given indexes
+func (p *Problem) distance(from, to int) (types.Distance, error) {
+	if from == to {
+		return 0, nil
+	}
+
+	distance := p.Distance
+	if distance == nil {
+		distance = Euclidean
+	}
+
+	val := distance(p.Nodes[from], p.Nodes[to])
+	if p.Scale != 0 {
+		val *= p.Scale
+	}
+
+	switch p.Rounding {
+	case RoundNearest:
+		val = math.Round(val)
+	case RoundUp:
+		val = math.Ceil(val)
+	case RoundDown:
+		val = math.Floor(val)
+	default:
+		return 0, fmt.Errorf("Unknown rounding mode %d", p.Rounding)
+	}
+
+	if math.IsNaN(val) || (val < 0) || (val > math.MaxUint32) {
+		return 0, fmt.Errorf("Invalid distance %v between nodes %d and %d", val, from, to)
+	}
+
+	return types.Distance(val), nil
+}
+
+// Distances builds a distance matrix for solver2 and solver3
+func (p *Problem) Distances() ([][]types.Distance, error) {
+	size := len(p.Nodes)
+	if size == 0 {
+		return nil, errors.New("Problem has no nodes")
+	}
+	if size > math.MaxUint8+1 {
+		return nil, errors.New("Problem has too many nodes")
+	}
+
+	backingArray := make([]types.Distance, size*size)
+	m := make([][]types.Distance, size)
+	for i := range m {
+		m[i] = backingArray[i*size : (i+1)*size]
+		for j := range m[i] {
+			val, err := p.distance(i, j)
+			if err != nil {
+				return nil, err
+			}
+			m[i][j] = val
+		}
+	}
+
+	return m, nil
+}
+
+// Matrix builds a distance matrix for solver. Diagonal values are set to -1
+func (p *Problem) Matrix() (matrix.Matrix, error) {
+	m, err := p.Distances()
+	if err != nil {
+		return nil, err
+	}
+
+	slice := make([][]int, len(m))
+	for i := range m {
+		slice[i] = make([]int, len(m))
+		for j, val := range m[i] {
+			if i == j {
+				slice[i][j] = -1
+				continue
+			}
+			slice[i][j] = int(val)
+		}
+	}
+
+	return matrix.ConvertToMatrix(slice), nil
+}
+
+// Route maps a solved path onto the problem nodes
+func (p *Problem) Route(path []types.Index) ([]Node, error) {
+	route := make([]Node, len(path))
+	for i, index := range path {
+		if int(index) >= len(p.Nodes) {
+			return nil, fmt.Errorf("Node %d is out of range", index)
+		}
+		route[i] = p.Nodes[index]
+	}
+
+	return route, nil
+}
+
+// IDs maps a solved path onto the node identifiers
+func (p *Problem) IDs(path []types.Index) ([]string, error) {
+	route, err := p.Route(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(route))
+	for i := range route {
+		ids[i] = route[i].ID
+	}
+
+	return ids, nil
+}
+
+// Tour creates a tour from a solved path with node identifiers and names
+func (p *Problem) Tour(path []types.Index) (tour.Tour, error) {
+	t, err := tour.New(path)
+	if err != nil {
+		return tour.Tour{}, err
+	}
+
+	t.IDs = make([]string, len(p.Nodes))
+	t.Labels = make([]string, len(p.Nodes))
+	for i, node := range p.Nodes {
+		t.IDs[i] = node.ID
+		t.Labels[i] = node.Label
+	}
+
+	return t, nil
+}
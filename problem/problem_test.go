@@ -0,0 +1,171 @@
+package problem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistanceFuncs(t *testing.T) {
+	a := Node{X: 1, Y: 1}
+	b := Node{X: 4, Y: 5}
+
+	assert.Equal(t, 5.0, Euclidean(a, b))
+	assert.Equal(t, 7.0, Manhattan(a, b))
+
+	// Paris - London, about 344 km
+	paris := Node{X: 2.3522, Y: 48.8566}
+	london := Node{X: -0.1276, Y: 51.5072}
+	assert.InDelta(t, 344, Haversine(paris, london), 1)
+	assert.InDelta(t, math.Pi*EarthRadius, Haversine(Node{X: 0, Y: 0}, Node{X: 180, Y: 0}), 1e-6)
+}
+
+func TestProblem_Distances(t *testing.T) {
+	nodes := []Node{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 0}}
+
+	tests := []struct {
+		name    string
+		problem Problem
+		want    [][]types.Distance
+		wantErr bool
+	}{
+		{
+			"Empty",
+			Problem{},
+			nil,
+			true,
+		},
+		{
+			"Euclidean - nearest",
+			Problem{Nodes: nodes, Scale: 10},
+			[][]types.Distance{
+				{0, 14, 20},
+				{14, 0, 14},
+				{20, 14, 0},
+			},
+			false,
+		},
+		{
+			"Euclidean - up",
+			Problem{Nodes: nodes, Distance: Euclidean, Rounding: RoundUp},
+			[][]types.Distance{
+				{0, 2, 2},
+				{2, 0, 2},
+				{2, 2, 0},
+			},
+			false,
+		},
+		{
+			"Euclidean - down",
+			Problem{Nodes: nodes, Rounding: RoundDown},
+			[][]types.Distance{
+				{0, 1, 2},
+				{1, 0, 1},
+				{2, 1, 0},
+			},
+			false,
+		},
+		{
+			"Manhattan",
+			Problem{Nodes: nodes, Distance: Manhattan},
+			[][]types.Distance{
+				{0, 2, 2},
+				{2, 0, 2},
+				{2, 2, 0},
+			},
+			false,
+		},
+		{
+			"Asymmetric function",
+			Problem{Nodes: nodes, Distance: func(a, b Node) float64 {
+				if a.X < b.X {
+					return 1
+				}
+				return 5
+			}},
+			[][]types.Distance{
+				{0, 1, 1},
+				{5, 0, 1},
+				{5, 5, 0},
+			},
+			false,
+		},
+		{
+			"Overflow",
+			Problem{Nodes: nodes, Scale: math.MaxUint32},
+			nil,
+			true,
+		},
+		{
+			"Unknown rounding",
+			Problem{Nodes: nodes, Rounding: Rounding(42)},
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.problem.Distances()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProblem_Matrix(t *testing.T) {
+	p := Problem{Nodes: []Node{{X: 0, Y: 0}, {X: 3, Y: 4}}}
+
+	m, err := p.Matrix()
+	assert.NoError(t, err)
+	assert.Equal(t, matrix.Matrix{{-1, 5}, {5, -1}}, m)
+
+	_, err = (&Problem{}).Matrix()
+	assert.Error(t, err)
+}
+
+func TestProblem_Route(t *testing.T) {
+	p := Problem{
+		Nodes: []Node{
+			{ID: "depot", X: 0, Y: 0},
+			{ID: "a", X: 10, Y: 10},
+			{ID: "b", X: 0, Y: 10},
+			{ID: "c", X: 10, Y: 0},
+		},
+		Distance: Manhattan,
+	}
+
+	m, err := p.Distances()
+	assert.NoError(t, err)
+
+	s := &solver2.Solver{}
+	path, dist, _, err := s.Solve(m)
+	assert.NoError(t, err)
+	assert.Equal(t, types.Distance(40), dist)
+
+	ids, err := p.IDs(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"depot", "b", "a", "c", "depot"}, ids)
+
+	route, err := p.Route(path)
+	assert.NoError(t, err)
+	assert.Equal(t, p.Nodes[2], route[1])
+
+	_, err = p.IDs([]types.Index{0, 4})
+	assert.Error(t, err)
+
+	p.Nodes[1].Label = "Store A"
+	tour, err := p.Tour(path)
+	assert.NoError(t, err)
+	assert.NoError(t, tour.Validate(m))
+	assert.Equal(t, dist, tour.Distance(m))
+	assert.Equal(t, "depot -> b -> Store A -> c -> depot", tour.String())
+}
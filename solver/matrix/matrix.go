@@ -1,6 +1,10 @@
 package matrix
 
-import "errors"
+import (
+	"errors"
+
+	flat "github.com/Spi1y/tsp-solver/matrix"
+)
 
 // Matrix is a square matrix.
 // It`s underlying [][]int slice is guaranteed to be sliced from one
@@ -11,15 +15,23 @@ type Matrix [][]int
 func ConvertToMatrix(slice [][]int) Matrix {
 	size := len(slice)
 
-	backingArray := make([]int, size*size)
-	var matrix Matrix = make([][]int, size)
-
+	m := flat.New(size, -1)
 	for i, row := range slice {
-		matrix[i] = backingArray[i*size : (i+1)*size]
-		copy(matrix[i], row)
+		copy(m.Row(i), row)
 	}
 
-	return matrix
+	return m.Rows()
+}
+
+// FromMatrix converts a common matrix to the solver one. Forbidden
+// elements and the diagonal are set to -1.
+func FromMatrix(source *flat.Matrix[int]) Matrix {
+	m := source.Replace(-1)
+	for i := 0; i < m.Size(); i++ {
+		m.Forbid(i, i)
+	}
+
+	return m.Rows()
 }
 
 // Copy copies matrix to a new one with some optimizations.
@@ -40,6 +52,38 @@ func (m Matrix) Copy() Matrix {
 	return matrix
 }
 
+// NewMany makes count zero matrices of the given size with a few
+// allocations in total. Each matrix is capped to its own part of the backing
+// array, so matrices stay independent and can be copied further.
+func NewMany(size, count int) []Matrix {
+	area := size * size
+
+	backingArray := make([]int, count*area)
+	rows := make([][]int, count*size)
+	matrices := make([]Matrix, count)
+
+	for k := range matrices {
+		data := backingArray[k*area : (k+1)*area : (k+1)*area]
+		matrix := Matrix(rows[k*size : (k+1)*size : (k+1)*size])
+		for i := range matrix {
+			matrix[i] = data[i*size : (i+1)*size : area]
+		}
+		matrices[k] = matrix
+	}
+
+	return matrices
+}
+
+// CopyMany makes count copies of the matrix, allocated with NewMany
+func (m Matrix) CopyMany(count int) []Matrix {
+	copies := NewMany(len(m), count)
+	for _, c := range copies {
+		c.LoadFrom(m)
+	}
+
+	return copies
+}
+
 // LoadFrom copies matrix data from another matrix
 func (m Matrix) LoadFrom(source Matrix) error {
 	size := len(m)
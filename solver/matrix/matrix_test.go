@@ -3,6 +3,7 @@ package matrix
 import (
 	"testing"
 
+	flat "github.com/Spi1y/tsp-solver/matrix"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -299,3 +300,59 @@ func TestMatrix_LoadFrom(t *testing.T) {
 		})
 	}
 }
+
+func TestFromMatrix(t *testing.T) {
+	source, err := flat.FromRows([][]int{
+		{0, 5, 7},
+		{3, 0, 1_000_000},
+		{2, 4, 0},
+	}, 1_000_000)
+	assert.NoError(t, err)
+
+	m := FromMatrix(source)
+	assert.Equal(t, Matrix{
+		{-1, 5, 7},
+		{3, -1, -1},
+		{2, 4, -1},
+	}, m)
+
+	// Result is sliced from one backing array, so it can be copied
+	assert.Equal(t, m, m.Copy())
+	assert.Equal(t, 0, source.At(0, 0))
+}
+
+func TestMatrix_CopyMany(t *testing.T) {
+	m := ConvertToMatrix([][]int{
+		{-1, 5, 7},
+		{3, -1, 1},
+		{2, 4, -1},
+	})
+
+	copies := m.CopyMany(3)
+	assert.Len(t, copies, 3)
+	for _, c := range copies {
+		assert.Equal(t, m, c)
+	}
+
+	// Copies are independent and can be copied further
+	copies[1].CutNode(0, 1, false)
+	assert.Equal(t, m, copies[0])
+	assert.Equal(t, m, copies[2])
+	assert.Equal(t, copies[1], copies[1].Copy())
+	assert.Equal(t, m, copies[2].Copy())
+
+	assert.Empty(t, ConvertToMatrix(nil).CopyMany(2)[1])
+}
+
+func TestNewMany(t *testing.T) {
+	matrices := NewMany(2, 2)
+	assert.Len(t, matrices, 2)
+	assert.Equal(t, Matrix{{0, 0}, {0, 0}}, matrices[1])
+
+	// Matrices are independent
+	matrices[0][1][1] = 5
+	assert.Equal(t, Matrix{{0, 0}, {0, 0}}, matrices[1])
+	assert.Equal(t, 5, matrices[0].Copy()[1][1])
+
+	assert.Empty(t, NewMany(3, 0))
+}
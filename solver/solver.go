@@ -1,6 +1,7 @@
 package solver
 
 import (
+	"context"
 	"errors"
 
 	"github.com/Spi1y/tsp-solver/solver/matrix"
@@ -16,10 +17,29 @@ type Solver struct {
 	bestSolutionDistance int
 
 	queue tasks.Queue
+
+	// Children are reduced in the scratch matrices, only the ones passing
+	// the bound are copied to the memory kept by tasks
+	scratch   []matrix.Matrix
+	survivors []survivor
+}
+
+// survivor is a child task passing the bound
+type survivor struct {
+	node     int
+	scratch  int
+	distance int
 }
 
 // Solve solves the TSP problem with a given distance matrix.
 func (s *Solver) Solve(q tasks.QueueType) ([]int, int, error) {
+	return s.SolveContext(context.Background(), q)
+}
+
+// SolveContext is the same as Solve, but the search can be interrupted with
+// the context. In that case the best solution found so far is returned along
+// with the context error.
+func (s *Solver) SolveContext(ctx context.Context, q tasks.QueueType) ([]int, int, error) {
 	size := len(s.DistanceMatrix)
 
 	if size == 0 {
@@ -43,7 +63,14 @@ func (s *Solver) Solve(q tasks.QueueType) ([]int, int, error) {
 	newTasks := s.solveTask(rootTask)
 	s.queue.Insert(newTasks)
 
+	done := ctx.Done()
 	for !s.queue.IsEmpty() {
+		select {
+		case <-done:
+			return s.bestSolution, s.bestSolutionDistance, ctx.Err()
+		default:
+		}
+
 		task := s.queue.PopFirst()
 		newTasks := s.solveTask(task)
 		s.queue.Insert(newTasks)
@@ -79,14 +106,20 @@ func (s *Solver) solveTask(task *tasks.Task) []*tasks.Task {
 	// Check if this is the last node of the path
 	closingNode := (nodesTraversed == nodesTotal-1)
 
-	newTasks := make([]*tasks.Task, 0, nodesTotal-nodesTraversed)
+	if (len(s.scratch) == 0) || (len(s.scratch[0]) != len(m)) {
+		s.scratch = matrix.NewMany(len(m), len(m))
+	}
+
+	survivors := s.survivors[:0]
+	child := 0
 	for nextNode := range m {
 		// Skip already visited nodes
 		if m[nextNode][0] == -1 {
 			continue
 		}
 
-		nodeMatrix := task.DistanceMatrix.Copy()
+		nodeMatrix := s.scratch[child]
+		nodeMatrix.LoadFrom(m)
 		nodeMatrix.CutNode(currentNode, nextNode, closingNode)
 		normalizationCost := nodeMatrix.Normalize()
 
@@ -98,22 +131,43 @@ func (s *Solver) solveTask(task *tasks.Task) []*tasks.Task {
 			continue
 		}
 
-		newPath := make([]int, 0, nodesTraversed+1)
-		newPath = append(newPath, task.Path...)
-		newPath = append(newPath, nextNode)
-
 		if closingNode {
 			// The path is finished and it`s better than the current best one
 			// Update the solver state
+			newPath := make([]int, nodesTraversed+2)
+			copy(newPath, task.Path)
+			newPath[len(newPath)-1] = nextNode
 			s.newSolutionFound(newPath, fullDistance)
 			return nil
 		}
 
-		newTasks = append(newTasks, &tasks.Task{
-			Path:           newPath,
-			Distance:       fullDistance,
-			DistanceMatrix: nodeMatrix,
+		survivors = append(survivors, survivor{
+			node:     nextNode,
+			scratch:  child,
+			distance: fullDistance,
 		})
+		child++
+	}
+	s.survivors = survivors
+
+	// Matrices and paths of the surviving children are allocated at once
+	matrices := matrix.NewMany(len(m), len(survivors))
+	newPathLen := nodesTraversed + 2
+	pathsSlice := make([]int, len(survivors)*newPathLen)
+
+	newTasks := make([]*tasks.Task, len(survivors))
+	for i, child := range survivors {
+		matrices[i].LoadFrom(s.scratch[child.scratch])
+
+		newPath := pathsSlice[i*newPathLen : (i+1)*newPathLen : (i+1)*newPathLen]
+		copy(newPath, task.Path)
+		newPath[newPathLen-1] = child.node
+
+		newTasks[i] = &tasks.Task{
+			Path:           newPath,
+			Distance:       child.distance,
+			DistanceMatrix: matrices[i],
+		}
 	}
 
 	return newTasks
@@ -1,6 +1,8 @@
 package solver
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/Spi1y/tsp-solver/solver/matrix"
@@ -53,6 +55,16 @@ func TestSolver_SolveWithHeap(t *testing.T) {
 	runSolverTest(t, tasks.QueueHeap)
 }
 
+func TestSolver_SolveCanceled(t *testing.T) {
+	tt := solveTestCase7Points()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := &Solver{DistanceMatrix: tt.distanceMatrix}
+	_, _, err := s.SolveContext(ctx, tasks.QueueHeap)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
 func TestSolver_solveTask(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -0,0 +1,176 @@
+package solver2
+
+import (
+	"math"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// noRow marks the end of the list of rows in bound.head and bound.next
+const noRow = -1
+
+// bound calculates lower estimates of all children of a task.
+//
+// The estimate of a child is the sum of row and column minimums of the
+// matrix with rows of unvisited nodes and columns of unvisited nodes plus the
+// root node, excluding the column of the next node. All children share the
+// same rows and differ only in one excluded column, so the submatrix is
+// gathered into a contiguous buffer and row-reduced once per parent. Row
+// minimum of a child differs from the parent one only for rows whose minimum
+// lies in the excluded column, and only these rows are reprocessed
+// to update column minimums of the child.
+//
+// Column j of the buffer is the root node for j == 0 and rows[j-1] otherwise,
+// so the column of row i and of the child rows[i] is i+1.
+type bound struct {
+	// Number of rows and columns of the submatrix
+	rows int
+	cols int
+
+	// Row-reduced submatrix, row by row. Diagonal elements are set
+	// to math.MaxUint32 so they never become minimums
+	reduced []types.Distance
+
+	// Smallest value of a row, its column and the difference between
+	// the second smallest and the smallest values
+	rowMin []types.Distance
+	rowArg []int
+	delta  []types.Distance
+	// Sum of all row minimums
+	rowSum types.Distance
+
+	// Column minimums of the reduced submatrix and their sum
+	colMin []types.Distance
+	colSum types.Distance
+
+	// Column minimums of the current child
+	childMin []types.Distance
+
+	// Rows with the minimum in the given column, as linked lists
+	head []int
+	next []int
+}
+
+// init allocates buffers for the matrix of the given size
+func (b *bound) init(size int) {
+	b.reduced = make([]types.Distance, size*size)
+	b.rowMin = make([]types.Distance, size)
+	b.rowArg = make([]int, size)
+	b.delta = make([]types.Distance, size)
+	b.colMin = make([]types.Distance, size)
+	b.childMin = make([]types.Distance, size)
+	b.head = make([]int, size)
+	b.next = make([]int, size)
+}
+
+// reset gathers and reduces the submatrix for the given unvisited nodes.
+// At least two nodes must be left.
+func (b *bound) reset(m [][]types.Distance, rows []types.Index) {
+	b.rows = len(rows)
+	b.cols = len(rows) + 1
+	b.rowSum = 0
+	b.colSum = 0
+
+	for j := 0; j < b.cols; j++ {
+		b.head[j] = noRow
+	}
+
+	for i, row := range rows {
+		rowSlice := m[row]
+		line := b.reduced[i*b.cols : (i+1)*b.cols]
+
+		// Gathering the row
+		line[0] = rowSlice[0]
+		for j, col := range rows {
+			line[j+1] = rowSlice[col]
+		}
+		line[i+1] = math.MaxUint32
+
+		// Two smallest values of the row
+		min, second := types.Distance(math.MaxUint32), types.Distance(math.MaxUint32)
+		arg := 0
+		for j, val := range line {
+			if val < min {
+				min, second = val, min
+				arg = j
+			} else if val < second {
+				second = val
+			}
+		}
+
+		// Reducing the row, diagonal is kept intact
+		for j := range line {
+			line[j] -= min
+		}
+		line[i+1] = math.MaxUint32
+
+		b.rowMin[i] = min
+		b.rowArg[i] = arg
+		b.delta[i] = second - min
+		b.rowSum += min
+
+		b.next[i] = b.head[arg]
+		b.head[arg] = i
+	}
+
+	// Column minimums of the reduced submatrix
+	copy(b.colMin[:b.cols], b.reduced[:b.cols])
+	for i := 1; i < b.rows; i++ {
+		line := b.reduced[i*b.cols : (i+1)*b.cols]
+		colMin := b.colMin[:len(line)]
+		for j, val := range line {
+			if val < colMin[j] {
+				colMin[j] = val
+			}
+		}
+	}
+	for _, val := range b.colMin[:b.cols] {
+		b.colSum += val
+	}
+}
+
+// columnMin returns the minimum of the column of the node with the given
+// index in rows over the reduced submatrix
+func (b *bound) columnMin(child int) types.Distance {
+	return b.colMin[child+1]
+}
+
+// estimate returns the lower estimate of the rest of the path after
+// visiting the node with the given index in rows
+func (b *bound) estimate(child int) types.Distance {
+	excluded := child + 1
+
+	if b.head[excluded] == noRow {
+		// Row minimums are the same as in the parent
+		return b.rowSum + b.colSum - b.colMin[excluded]
+	}
+
+	estimate := b.rowSum
+	childMin := b.childMin[:b.cols]
+	copy(childMin, b.colMin[:b.cols])
+
+	for i := b.head[excluded]; i != noRow; i = b.next[i] {
+		delta := b.delta[i]
+		estimate += delta
+
+		line := b.reduced[i*b.cols : (i+1)*b.cols]
+		for j, val := range line {
+			if (j == excluded) || (j == i+1) {
+				continue
+			}
+
+			val -= delta
+			if val < childMin[j] {
+				childMin[j] = val
+			}
+		}
+	}
+
+	for j, val := range childMin {
+		if j != excluded {
+			estimate += val
+		}
+	}
+
+	return estimate
+}
@@ -0,0 +1,131 @@
+package solver2
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/gen"
+	"github.com/Spi1y/tsp-solver/solver2/iterator"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoundEstimate(t *testing.T) {
+	for _, kind := range gen.Kinds {
+		for _, size := range []int{3, 5, 9, 14} {
+			t.Run(fmt.Sprintf("%v-%d", kind, size), func(t *testing.T) {
+				m, err := gen.Generate(gen.Options{Kind: kind, Size: size, Seed: int64(size)})
+				assert.NoError(t, err)
+
+				it := &iterator.Iterator{}
+				it.Init(types.Index(size))
+				b := &bound{}
+				b.init(size)
+				r := rand.New(rand.NewSource(int64(size)))
+
+				for _, path := range boundTestPaths(r, size) {
+					assert.NoError(t, it.SetPath(path))
+					nextNodes := it.NodesToVisit()
+					if len(nextNodes) < 2 {
+						continue
+					}
+
+					b.reset(m, it.RowsToIterate())
+					for i, nextNode := range nextNodes {
+						expected := referenceEstimate(t, m, it, nextNode)
+						assert.Equal(t, expected, b.estimate(i), "path %v, next node %v", path, nextNode)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkBoundEstimate(b *testing.B) {
+	for _, size := range []int{13, 15, 17} {
+		m, err := gen.Generate(gen.Options{Kind: gen.Asymmetric, Size: size, Seed: 1})
+		if err != nil {
+			b.Fatal(err)
+		}
+		it := &iterator.Iterator{}
+		it.Init(types.Index(size))
+		it.SetPath([]types.Index{0})
+		nextNodes := it.NodesToVisit()
+
+		b.Run(fmt.Sprintf("Reference-%d", size), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				for _, nextNode := range nextNodes {
+					referenceEstimate(b, m, it, nextNode)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("Bound-%d", size), func(b *testing.B) {
+			bnd := &bound{}
+			bnd.init(size)
+			for n := 0; n < b.N; n++ {
+				bnd.reset(m, it.RowsToIterate())
+				for i := range nextNodes {
+					bnd.estimate(i)
+				}
+			}
+		})
+	}
+}
+
+// boundTestPaths returns the root path and several random partial paths
+func boundTestPaths(r *rand.Rand, size int) [][]types.Index {
+	paths := [][]types.Index{{0}}
+	for i := 0; i < 10; i++ {
+		perm := r.Perm(size - 1)
+		path := []types.Index{0}
+		for _, node := range perm[:r.Intn(size-1)] {
+			path = append(path, types.Index(node+1))
+		}
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+// referenceEstimate calculates the estimate directly, reducing the rows
+// and the columns of the submatrix for the next node
+func referenceEstimate(tb testing.TB, m [][]types.Distance, it *iterator.Iterator, nextNode types.Index) types.Distance {
+	cols, err := it.ColsToIterate(nextNode)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	var estimate types.Distance
+	colMin := make([]types.Distance, len(cols))
+	for i := range colMin {
+		colMin[i] = math.MaxUint32
+	}
+	for _, row := range it.RowsToIterate() {
+		min := m[row][0]
+		for _, col := range cols {
+			if (row != col) && (min > m[row][col]) {
+				min = m[row][col]
+			}
+		}
+		estimate += min
+
+		for colIndex, col := range cols {
+			if row == col {
+				continue
+			}
+			val := m[row][col] - min
+			if colMin[colIndex] > val {
+				colMin[colIndex] = val
+			}
+		}
+	}
+
+	for _, val := range colMin {
+		estimate += val
+	}
+
+	return estimate
+}
@@ -0,0 +1,96 @@
+package solver2
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Spi1y/tsp-solver/solver2/tasks"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Branching is a strategy of choosing between tasks with equal estimates.
+// It sets the rank of each child of an expanded task, and all queues pop
+// tasks with lower ranks first among tasks with equal estimates.
+type Branching int
+
+const (
+	// BranchIndex sets no ranks, tasks with equal estimates are popped in
+	// the own order of the queue
+	BranchIndex Branching = iota
+	// BranchEstimate prefers tasks with the smaller remaining part of
+	// the estimate, that is with more of it already traveled
+	BranchEstimate
+	// BranchEdge prefers tasks which came by a cheaper edge
+	BranchEdge
+	// BranchDeep prefers tasks with longer paths, which leads to complete
+	// solutions earlier
+	BranchDeep
+	// BranchAlternative prefers tasks which came by an edge with a worse
+	// alternative: the cost of the cheapest other edge from the node minus
+	// the cost of this edge, plus the column minimum of the next node
+	BranchAlternative
+)
+
+// Branchings lists all branching strategies
+var Branchings = []Branching{BranchIndex, BranchEstimate, BranchEdge, BranchDeep, BranchAlternative}
+
+// String implements the Stringer interface
+func (b Branching) String() string {
+	switch b {
+	case BranchIndex:
+		return "index"
+	case BranchEstimate:
+		return "estimate"
+	case BranchEdge:
+		return "edge"
+	case BranchDeep:
+		return "deep"
+	case BranchAlternative:
+		return "alternative"
+	}
+
+	return fmt.Sprintf("Branching(%d)", int(b))
+}
+
+// rankChildren sets ranks of children of the task according to the branching
+// strategy. Children must be in the order of nextNodes and the bound must be
+// reset for the task.
+func (s *Solver) rankChildren(children []tasks.Task, currNode types.Index, nextNodes []types.Index) {
+	row := s.matrix[currNode]
+
+	switch s.Branching {
+	case BranchEstimate:
+		for i := range children {
+			children[i].Rank = int64(children[i].Estimate) - int64(children[i].Distance)
+		}
+	case BranchEdge:
+		for i, nextNode := range nextNodes {
+			children[i].Rank = int64(row[nextNode])
+		}
+	case BranchDeep:
+		for i := range children {
+			children[i].Rank = -int64(children[i].Depth)
+		}
+	case BranchAlternative:
+		// Two smallest edges of the row, so the cheapest other edge
+		// is known for each edge
+		cheapest, second := int64(math.MaxInt64), int64(math.MaxInt64)
+		for _, nextNode := range nextNodes {
+			val := int64(row[nextNode])
+			if val < cheapest {
+				cheapest, second = val, cheapest
+			} else if val < second {
+				second = val
+			}
+		}
+
+		for i, nextNode := range nextNodes {
+			val := int64(row[nextNode])
+			other := cheapest
+			if val == cheapest {
+				other = second
+			}
+			children[i].Rank = -(other - val + int64(s.bound.columnMin(i)))
+		}
+	}
+}
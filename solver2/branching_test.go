@@ -0,0 +1,94 @@
+package solver2
+
+import (
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/solver2/tasks"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolverSolveBranching(t *testing.T) {
+	tests := solverTestCases()
+	for _, b := range Branchings {
+		for _, tt := range tests {
+			t.Run(b.String()+"/"+tt.name, func(t *testing.T) {
+				s := &Solver{Branching: b}
+				_, dist, _, err := s.Solve(tt.distanceMatrix)
+
+				assert.NoError(t, err)
+				assert.Equal(t, tt.dist, dist)
+			})
+		}
+	}
+}
+
+func TestSolverRankChildren(t *testing.T) {
+	m := [][]types.Distance{
+		{0, 9, 2, 3},
+		{3, 0, 4, 9},
+		{6, 7, 0, 12},
+		{2, 6, 3, 0},
+	}
+
+	tests := []struct {
+		branching Branching
+		want      []types.Index
+	}{
+		// Edges are 9, 2 and 3
+		{BranchEdge, []types.Index{2, 3, 1}},
+		// Regrets are -6, 2 and 5
+		{BranchAlternative, []types.Index{3, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.branching.String(), func(t *testing.T) {
+			s := &Solver{Branching: tt.branching}
+			assert.NoError(t, s.init(m))
+
+			root, err := s.attach(tasks.Task{Path: []types.Index{0}})
+			assert.NoError(t, err)
+			children := make([]tasks.Task, len(m))
+			count, err := s.solveTask(root, children)
+			assert.NoError(t, err)
+
+			// Children with equal estimates are popped in the order of ranks
+			// from every queue
+			for _, qt := range tasks.QueueTypes {
+				q := tasks.CreateQueue(qt)
+				for _, child := range children[:count] {
+					child.Estimate = 10
+					q.InsertSingle(child)
+				}
+
+				var order []types.Index
+				for task, err := q.PopFirst(); err == nil; task, err = q.PopFirst() {
+					order = append(order, s.tree.Last(task.Node))
+				}
+				assert.Equal(t, tt.want, order, qt.String())
+			}
+		})
+	}
+}
+
+func TestSolverRankChildren_Tasks(t *testing.T) {
+	s := &Solver{}
+	assert.NoError(t, s.init(solveTestCase7Points().distanceMatrix))
+	children := []tasks.Task{
+		{Depth: 2, Distance: 5, Estimate: 9},
+		{Depth: 3, Distance: 2, Estimate: 9},
+	}
+
+	// No ranks by default
+	s.rankChildren(children, 0, []types.Index{1, 2})
+	assert.Equal(t, children[0].Rank, children[1].Rank)
+
+	// More of the estimate is traveled
+	s.Branching = BranchEstimate
+	s.rankChildren(children, 0, []types.Index{1, 2})
+	assert.Less(t, children[0].Rank, children[1].Rank)
+
+	// Deeper tasks go first
+	s.Branching = BranchDeep
+	s.rankChildren(children, 0, []types.Index{1, 2})
+	assert.Less(t, children[1].Rank, children[0].Rank)
+}
@@ -0,0 +1,177 @@
+// Package checkpoint implements snapshots of the solver state, which allow
+// to resume a long search after the process restart.
+package checkpoint
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Spi1y/tsp-solver/solver2/stats"
+	"github.com/Spi1y/tsp-solver/solver2/tasks"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Version of the checkpoint format
+const Version = 1
+
+// ErrMismatch is returned when a checkpoint was made for another distance matrix
+var ErrMismatch = errors.New("Checkpoint does not match the distance matrix")
+
+// Checkpoint is a snapshot of the search state
+type Checkpoint struct {
+	// Format version, checkpoints of other versions are rejected
+	Version int
+	// Hash of the distance matrix the search was started with
+	MatrixHash [sha256.Size]byte
+	// Open tasks of the search
+	Tasks []tasks.Task
+	// Best solution found so far, empty if there is none
+	Path     []types.Index
+	Distance types.Distance
+	// Statistics accumulated before the snapshot
+	Stats stats.Stats
+}
+
+// New creates a checkpoint of the search on a given matrix
+func New(m [][]types.Distance) *Checkpoint {
+	return &Checkpoint{
+		Version:    Version,
+		MatrixHash: Hash(m),
+	}
+}
+
+// Snapshot makes a checkpoint of the search on a given matrix with open tasks
+// of the queue and the best solution found so far. Tasks are passed through
+// detach if it is set, so solvers keeping paths outside of tasks can
+// restore them.
+func Snapshot(m [][]types.Distance, q tasks.Queue, path []types.Index, distance types.Distance, st stats.Stats, detach func(tasks.Task) tasks.Task) (*Checkpoint, error) {
+	if q == nil {
+		return nil, errors.New("Solver has no search to checkpoint")
+	}
+
+	c := New(m)
+	c.Tasks = q.Tasks()
+	if detach != nil {
+		for i := range c.Tasks {
+			c.Tasks[i] = detach(c.Tasks[i])
+		}
+	}
+	c.Path = path
+	c.Distance = distance
+	c.Stats = st
+
+	return c, nil
+}
+
+// Restore inserts open tasks into the queue, passing them through attach if
+// it is set. It returns statistics to continue with and the start time
+// shifted back by the duration of previous runs.
+func (c *Checkpoint) Restore(q tasks.Queue, attach func(tasks.Task) (tasks.Task, error)) (stats.Stats, time.Time, error) {
+	// Tasks were already counted as created before the snapshot
+	for _, task := range c.Tasks {
+		if attach != nil {
+			var err error
+			task, err = attach(task)
+			if err != nil {
+				return stats.Stats{}, time.Time{}, err
+			}
+		}
+		q.InsertSingle(task)
+	}
+
+	st := c.Stats
+	st.UpdateQueueLen(q.Len())
+
+	return st, time.Now().Add(-c.Stats.Duration), nil
+}
+
+// Hash calculates a hash of the distance matrix
+func Hash(m [][]types.Distance) [sha256.Size]byte {
+	h := sha256.New()
+	buf := make([]byte, 4)
+
+	binary.LittleEndian.PutUint32(buf, uint32(len(m)))
+	h.Write(buf)
+	for _, row := range m {
+		binary.LittleEndian.PutUint32(buf, uint32(len(row)))
+		h.Write(buf)
+		for _, val := range row {
+			binary.LittleEndian.PutUint32(buf, uint32(val))
+			h.Write(buf)
+		}
+	}
+
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+
+	return sum
+}
+
+// Check verifies that the checkpoint was made for a given matrix
+func (c *Checkpoint) Check(m [][]types.Distance) error {
+	if c.MatrixHash != Hash(m) {
+		return ErrMismatch
+	}
+
+	return nil
+}
+
+// Write encodes the checkpoint to the writer
+func (c *Checkpoint) Write(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(c)
+}
+
+// Read decodes a checkpoint from the reader
+func Read(r io.Reader) (*Checkpoint, error) {
+	c := &Checkpoint{}
+	err := gob.NewDecoder(r).Decode(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Version != Version {
+		return nil, errors.New("Unsupported checkpoint version")
+	}
+
+	return c, nil
+}
+
+// WriteFile saves the checkpoint to a file. The file is replaced atomically,
+// so a previous checkpoint is not lost if the process dies while writing.
+func (c *Checkpoint) WriteFile(name string) error {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = c.Write(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), name)
+}
+
+// ReadFile loads a checkpoint from a file
+func ReadFile(name string) (*Checkpoint, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
@@ -0,0 +1,133 @@
+package checkpoint
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Spi1y/tsp-solver/solver2/stats"
+	"github.com/Spi1y/tsp-solver/solver2/tasks"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpoint_Check(t *testing.T) {
+	m := testMatrix()
+	c := New(m)
+	assert.NoError(t, c.Check(m))
+
+	changed := testMatrix()
+	changed[1][2]++
+	assert.Equal(t, ErrMismatch, c.Check(changed))
+
+	// Same values in a different shape
+	assert.Equal(t, ErrMismatch, c.Check([][]types.Distance{{0, 1, 2, 3, 0, 4, 5, 6, 0}}))
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	m := testMatrix()
+	_, err := Snapshot(m, nil, nil, 0, stats.Stats{}, nil)
+	assert.Error(t, err)
+
+	q := tasks.CreateQueue(tasks.QueueHeap)
+	q.Insert([]tasks.Task{{Node: 1, Distance: 3, Estimate: 5}, {Node: 2, Distance: 2, Estimate: 4}})
+	detach := func(task tasks.Task) tasks.Task {
+		task.Path = []types.Index{0, types.Index(task.Node)}
+		task.Node = 0
+		return task
+	}
+	st := stats.Stats{TasksCreated: 7, Duration: time.Hour}
+	c, err := Snapshot(m, q, []types.Index{0, 1, 2, 0}, 9, st, detach)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Check(m))
+	assert.Len(t, c.Tasks, 2)
+	for _, task := range c.Tasks {
+		assert.Len(t, task.Path, 2)
+	}
+	assert.Equal(t, types.Distance(9), c.Distance)
+
+	restored := tasks.CreateQueue(tasks.QueueHeap)
+	attach := func(task tasks.Task) (tasks.Task, error) {
+		task.Node = tasks.NodeID(task.Path[1])
+		task.Path = nil
+		return task, nil
+	}
+	rst, start, err := c.Restore(restored, attach)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, rst.TasksCreated)
+	assert.Equal(t, 2, rst.MaxQueueLen)
+	assert.True(t, time.Since(start) >= time.Hour)
+
+	first, err := restored.PopFirst()
+	assert.NoError(t, err)
+	assert.Equal(t, tasks.Task{Node: 2, Distance: 2, Estimate: 4}, first)
+
+	_, _, err = c.Restore(restored, func(task tasks.Task) (tasks.Task, error) {
+		return task, tasks.ErrTreeFull
+	})
+	assert.Equal(t, tasks.ErrTreeFull, err)
+}
+
+func TestCheckpoint_WriteRead(t *testing.T) {
+	c := testCheckpoint()
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.Write(&buf))
+
+	restored, err := Read(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, c, restored)
+
+	c.Version = Version + 1
+	buf.Reset()
+	assert.NoError(t, c.Write(&buf))
+	_, err = Read(&buf)
+	assert.Error(t, err)
+}
+
+func TestCheckpoint_WriteFile(t *testing.T) {
+	c := testCheckpoint()
+	name := filepath.Join(t.TempDir(), "solve.checkpoint")
+
+	_, err := ReadFile(name)
+	assert.Error(t, err)
+
+	assert.NoError(t, c.WriteFile(name))
+	c.Distance = 42
+	assert.NoError(t, c.WriteFile(name))
+
+	restored, err := ReadFile(name)
+	assert.NoError(t, err)
+	assert.Equal(t, c, restored)
+
+	// Temporary files are cleaned up
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(name), "*"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{name}, files)
+}
+
+func testCheckpoint() *Checkpoint {
+	c := New(testMatrix())
+	c.Tasks = []tasks.Task{
+		{Path: []types.Index{0, 1}, Distance: 1, Estimate: 10},
+		{Path: []types.Index{0, 2}, Distance: 2, Estimate: 11},
+	}
+	c.Path = []types.Index{0, 1, 2, 0}
+	c.Distance = 12
+	c.Stats = stats.Stats{
+		TasksCreated:  3,
+		TasksExpanded: 1,
+		Incumbents:    []stats.Incumbent{{Distance: 12, Elapsed: 5}},
+	}
+
+	return c
+}
+
+func testMatrix() [][]types.Distance {
+	return [][]types.Distance{
+		{0, 1, 2},
+		{3, 0, 4},
+		{5, 6, 0},
+	}
+}
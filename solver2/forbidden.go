@@ -0,0 +1,19 @@
+package solver2
+
+import (
+	"context"
+
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2/forbidden"
+	"github.com/Spi1y/tsp-solver/solver2/stats"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// ErrNoTour is returned when every tour uses a forbidden edge
+var ErrNoTour = forbidden.ErrNoTour
+
+// SolveMatrix is the same as SolveContext, but accepts a matrix with forbidden
+// edges. Forbidden edges are replaced with a penalty, see package forbidden.
+func (s *Solver) SolveMatrix(ctx context.Context, m *matrix.Matrix[types.Distance]) ([]types.Index, types.Distance, stats.Stats, error) {
+	return forbidden.Solve(ctx, m, s.SolveContext)
+}
@@ -0,0 +1,60 @@
+// Package forbidden lets solvers working with plain distances accept
+// matrices with forbidden edges. Forbidden edges are replaced with a penalty,
+// which is bigger than any tour without them, yet small enough to keep
+// estimates from overflowing.
+package forbidden
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2/stats"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// ErrNoTour is returned when every tour uses a forbidden edge
+var ErrNoTour = errors.New("No tour avoids forbidden edges")
+
+// SolveFunc solves the TSP for a plain distance matrix
+type SolveFunc func(ctx context.Context, m [][]types.Distance) ([]types.Index, types.Distance, stats.Stats, error)
+
+// Penalty returns the distance replacing forbidden edges of the matrix
+func Penalty(m *matrix.Matrix[types.Distance]) (types.Distance, error) {
+	size := m.Size()
+	if size == 0 {
+		return 0, errors.New("Distance matrix is empty")
+	}
+
+	// Estimates sum up to 3*size elements
+	penalty := types.Distance(math.MaxUint32 / (3 * uint64(size)))
+	for i := 0; i < size; i++ {
+		for j, val := range m.Row(i) {
+			if (i != j) && !m.Forbidden(i, j) && (uint64(val)*uint64(size) >= uint64(penalty)) {
+				return 0, errors.New("Distances are too large to forbid edges")
+			}
+		}
+	}
+
+	return penalty, nil
+}
+
+// Solve solves the matrix with forbidden edges replaced by the penalty.
+// If the best found tour uses a forbidden edge, no tour is returned.
+func Solve(ctx context.Context, m *matrix.Matrix[types.Distance], solve SolveFunc) ([]types.Index, types.Distance, stats.Stats, error) {
+	penalty, err := Penalty(m)
+	if err != nil {
+		return nil, 0, stats.Stats{}, err
+	}
+
+	path, dist, st, err := solve(ctx, m.Replace(penalty).Rows())
+	if dist >= penalty {
+		if err == nil {
+			err = ErrNoTour
+		}
+		return nil, 0, st, err
+	}
+
+	return path, dist, st, err
+}
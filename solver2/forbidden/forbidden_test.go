@@ -0,0 +1,59 @@
+package forbidden
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver2/stats"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPenalty(t *testing.T) {
+	m := matrix.New[types.Distance](3, math.MaxUint32)
+	penalty, err := Penalty(m)
+	assert.NoError(t, err)
+	assert.Equal(t, types.Distance(math.MaxUint32/9), penalty)
+
+	m.Set(0, 1, math.MaxUint32/4)
+	_, err = Penalty(m)
+	assert.Error(t, err)
+
+	_, err = Penalty(matrix.New[types.Distance](0, math.MaxUint32))
+	assert.Error(t, err)
+}
+
+func TestSolve(t *testing.T) {
+	m, err := matrix.FromRows([][]types.Distance{
+		{0, 1, 2},
+		{3, 0, 4},
+		{5, 6, 0},
+	}, math.MaxUint32)
+	assert.NoError(t, err)
+	m.Forbid(0, 1)
+	penalty, err := Penalty(m)
+	assert.NoError(t, err)
+
+	// Solver sees the penalty instead of the forbidden edge
+	var dist types.Distance
+	solve := func(ctx context.Context, rows [][]types.Distance) ([]types.Index, types.Distance, stats.Stats, error) {
+		assert.Equal(t, penalty, rows[0][1])
+		assert.Equal(t, types.Distance(2), rows[0][2])
+		return []types.Index{0, 2, 1, 0}, dist, stats.Stats{}, nil
+	}
+
+	dist = 13
+	path, got, _, err := Solve(context.Background(), m, solve)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Index{0, 2, 1, 0}, path)
+	assert.Equal(t, dist, got)
+
+	// Best tour uses the forbidden edge
+	dist = penalty + 10
+	path, _, _, err = Solve(context.Background(), m, solve)
+	assert.True(t, errors.Is(err, ErrNoTour))
+	assert.Nil(t, path)
+}
@@ -17,7 +17,6 @@ func TestSolver_SolveRecursively(t *testing.T) {
 				matrix:               tt.distanceMatrix,
 				bestSolution:         []types.Index{},
 				bestSolutionDistance: 0,
-				buffer:               make([]types.Distance, len(tt.distanceMatrix)),
 				taskQueue:            tasks.NewHeapQueue(),
 				iterator:             &iterator.Iterator{},
 			}
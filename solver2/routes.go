@@ -0,0 +1,176 @@
+package solver2
+
+import (
+	"math"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// routes is the state of routes of a path, used when the longest route is
+// minimized (see Solver.Depots)
+type routes struct {
+	// Distance traveled before the current route started
+	closed types.Distance
+	// Length of the longest finished route
+	longest types.Distance
+	// Number of depots left to visit, each of them starts one more route
+	depots int
+	// Longest shortest round trip from a depot through an unvisited node,
+	// every route which visits the node is not shorter
+	reach types.Distance
+
+	// Routes are built in a canonical order to skip their permutations.
+	// Depots are visited in the order of indices, and routes which are not
+	// idle in the order of their first nodes.
+	nextDepot types.Index
+	lastFirst types.Index
+}
+
+// close finishes the current route at the given distance of the path
+func (r *routes) close(distance types.Distance) {
+	if length := distance - r.closed; length > r.longest {
+		r.longest = length
+	}
+	r.closed = distance
+}
+
+// routes collects the state of routes of the current path, nodes to visit
+// are the rest of the tour
+func (s *Solver) routes(nextNodes []types.Index) routes {
+	var r routes
+	var distance types.Distance
+	for i := 1; i < len(s.path); i++ {
+		prev, node := s.path[i-1], s.path[i]
+		distance += s.matrix[prev][node]
+		if s.Depots[node] {
+			r.close(distance)
+		} else if (i == 1) || s.Depots[prev] {
+			r.lastFirst = node
+		}
+	}
+
+	for _, node := range nextNodes {
+		if !s.Depots[node] {
+			if trip := s.fromDepot[node] + s.toDepot[node]; trip > r.reach {
+				r.reach = trip
+			}
+			continue
+		}
+
+		if r.depots == 0 {
+			r.nextDepot = node
+		}
+		r.depots++
+	}
+
+	return r
+}
+
+// canonical checks if the next node keeps routes in the canonical order
+func (s *Solver) canonical(r routes, currNode, next types.Index) bool {
+	if s.Depots[next] {
+		return next == r.nextDepot
+	}
+	if (currNode == 0) || s.Depots[currNode] {
+		return next > r.lastFirst
+	}
+
+	return true
+}
+
+// routeEstimate returns the lower estimate of the longest route for the
+// child, which enters the next node. Distance is the path distance of the
+// child and estimate is the lower estimate of its tour length.
+func (s *Solver) routeEstimate(r routes, next types.Index, distance, estimate types.Distance) types.Distance {
+	// Routes which are not finished yet, including the current one
+	open := uint64(r.depots + 1)
+	if s.Depots[next] {
+		r.close(distance)
+		open--
+	}
+
+	result := r.longest
+	if r.reach > result {
+		result = r.reach
+	}
+
+	// Current route has to return to a depot
+	current := distance - r.closed
+	if !s.Depots[next] {
+		current += s.toDepot[next]
+	}
+	if current > result {
+		result = current
+	}
+
+	// The rest of the tour is shared by open routes, and at least one of
+	// them is not shorter than the average
+	rest := uint64(estimate - r.closed)
+	if average := types.Distance((rest + open - 1) / open); average > result {
+		result = average
+	}
+
+	return result
+}
+
+// longestRoute returns the length of the longest route of the tour, which
+// ends with the final node and the return to the root node
+func (s *Solver) longestRoute(r routes, currNode, finalNode types.Index, distance types.Distance) types.Distance {
+	distance += s.matrix[currNode][finalNode]
+	if s.Depots[finalNode] {
+		r.close(distance)
+	}
+	r.close(distance + s.matrix[finalNode][0])
+
+	return r.longest
+}
+
+// initRoutes calculates shortest distances from depots to every node and
+// back, which estimate the length of routes visiting the node
+func (s *Solver) initRoutes() {
+	size := len(s.matrix)
+	s.fromDepot = shortestDistances(size, s.Depots, func(i, j int) types.Distance { return s.matrix[i][j] })
+	s.toDepot = shortestDistances(size, s.Depots, func(i, j int) types.Distance { return s.matrix[j][i] })
+}
+
+// shortestDistances runs Dijkstra's algorithm from all sources at once
+// on the dense graph with the given edge lengths
+func shortestDistances(size int, sources []bool, edge func(i, j int) types.Distance) []types.Distance {
+	dist := make([]uint64, size)
+	done := make([]bool, size)
+	for i := range dist {
+		dist[i] = math.MaxUint64
+		if (i == 0) || sources[i] {
+			dist[i] = 0
+		}
+	}
+
+	for {
+		curr := -1
+		for i := range dist {
+			if !done[i] && ((curr == -1) || (dist[i] < dist[curr])) {
+				curr = i
+			}
+		}
+		if curr == -1 {
+			break
+		}
+		done[curr] = true
+
+		for next := range dist {
+			if !done[next] && (dist[curr]+uint64(edge(curr, next)) < dist[next]) {
+				dist[next] = dist[curr] + uint64(edge(curr, next))
+			}
+		}
+	}
+
+	result := make([]types.Distance, size)
+	for i, d := range dist {
+		if d > math.MaxUint32 {
+			d = math.MaxUint32
+		}
+		result[i] = types.Distance(d)
+	}
+
+	return result
+}
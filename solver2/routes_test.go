@@ -0,0 +1,126 @@
+package solver2
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/gen"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShortestDistances(t *testing.T) {
+	m := [][]types.Distance{
+		{0, 10, 1, 50},
+		{10, 0, 20, 5},
+		{1, 1, 0, 40},
+		{50, 5, 40, 0},
+	}
+	edge := func(i, j int) types.Distance { return m[i][j] }
+
+	assert.Equal(t, []types.Distance{0, 2, 1, 7}, shortestDistances(4, make([]bool, 4), edge))
+	assert.Equal(t, []types.Distance{0, 2, 1, 0}, shortestDistances(4, []bool{false, false, false, true}, edge))
+}
+
+func TestSolverDepots(t *testing.T) {
+	for _, kind := range []gen.Kind{gen.Uniform, gen.Asymmetric} {
+		for depots := 1; depots <= 3; depots++ {
+			t.Run(fmt.Sprintf("%v/%d", kind, depots), func(t *testing.T) {
+				m, marks := depotsMatrix(t, kind, 5, depots)
+
+				s := &Solver{Depots: marks}
+				path, dist, _, err := s.Solve(m)
+				assert.NoError(t, err)
+				assert.Len(t, path, len(m)+1)
+				assert.Equal(t, longestRouteOf(m, marks, path), dist)
+				assert.Equal(t, bruteForceLongest(m, marks), dist)
+			})
+		}
+	}
+
+	s := &Solver{Depots: []bool{true}}
+	_, _, _, err := s.Solve(solveTestCase4Points().distanceMatrix)
+	assert.Error(t, err)
+}
+
+// depotsMatrix generates a matrix of the given size with copies of the root
+// node added as depots. Depots can not follow each other.
+func depotsMatrix(t *testing.T, kind gen.Kind, size int, depots int) ([][]types.Distance, []bool) {
+	rows, err := gen.Generate(gen.Options{Kind: kind, Size: size, Seed: int64(depots), MaxDistance: 100})
+	assert.NoError(t, err)
+
+	total := size + depots
+	m := make([][]types.Distance, total)
+	marks := make([]bool, total)
+	original := func(node int) int {
+		if node >= size {
+			return 0
+		}
+		return node
+	}
+	for i := range m {
+		m[i] = make([]types.Distance, total)
+		marks[i] = original(i) == 0
+		for j := range m[i] {
+			switch {
+			case i == j:
+			case marks[i] && marks[j]:
+				m[i][j] = 10_000
+			default:
+				m[i][j] = rows[original(i)][original(j)]
+			}
+		}
+	}
+
+	return m, marks
+}
+
+// longestRouteOf returns the length of the longest route of the tour
+func longestRouteOf(m [][]types.Distance, depots []bool, path []types.Index) types.Distance {
+	var longest, route types.Distance
+	for i := 1; i < len(path); i++ {
+		route += m[path[i-1]][path[i]]
+		if depots[path[i]] || (path[i] == 0) {
+			if route > longest {
+				longest = route
+			}
+			route = 0
+		}
+	}
+
+	return longest
+}
+
+// bruteForceLongest enumerates all tours and returns the minimal longest route
+func bruteForceLongest(m [][]types.Distance, depots []bool) types.Distance {
+	size := len(m)
+	path := []types.Index{0}
+	used := make([]bool, size)
+	used[0] = true
+
+	var best types.Distance
+	var visit func()
+	visit = func() {
+		if len(path) == size {
+			longest := longestRouteOf(m, depots, append(path, 0))
+			if (best == 0) || (longest < best) {
+				best = longest
+			}
+			return
+		}
+
+		for next := 1; next < size; next++ {
+			if used[next] {
+				continue
+			}
+			used[next] = true
+			path = append(path, types.Index(next))
+			visit()
+			path = path[:len(path)-1]
+			used[next] = false
+		}
+	}
+	visit()
+
+	return best
+}
@@ -0,0 +1,81 @@
+// Package searchlog writes debug records of the search, shared by solvers.
+// All functions do nothing without a logger.
+package searchlog
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/Spi1y/tsp-solver/solver2/stats"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// DefaultEvery is the default number of expanded tasks between progress log records
+const DefaultEvery = 100_000
+
+// Every returns the configured number of expanded tasks between progress
+// log records, zero or negative value means DefaultEvery
+func Every(every int) int {
+	if every <= 0 {
+		return DefaultEvery
+	}
+
+	return every
+}
+
+// Path converts the path to ints, so it is logged as a list of nodes
+// rather than a byte string
+func Path(path []types.Index) []int {
+	result := make([]int, len(path))
+	for i, node := range path {
+		result[i] = int(node)
+	}
+
+	return result
+}
+
+// Incumbent logs a new best solution
+func Incumbent(l *slog.Logger, distance types.Distance, path []types.Index, expanded int, start time.Time) {
+	if l == nil {
+		return
+	}
+
+	l.Debug("new incumbent",
+		"distance", distance,
+		"path", Path(path),
+		"expanded", expanded,
+		"elapsed", time.Since(start))
+}
+
+// Progress logs the state of the search. Solvers may add their own
+// attributes, like the current lower bound.
+func Progress(l *slog.Logger, expanded, queue int, best types.Distance, start time.Time, args ...any) {
+	if l == nil {
+		return
+	}
+
+	attrs := []any{
+		"expanded", expanded,
+		"queue", queue,
+		"best", best,
+	}
+	attrs = append(attrs, args...)
+	attrs = append(attrs, "elapsed", time.Since(start))
+	l.Debug("search progress", attrs...)
+}
+
+// Finish logs the end of the search, err is the reason of the interruption
+func Finish(l *slog.Logger, err error, best types.Distance, st stats.Stats) {
+	if l == nil {
+		return
+	}
+
+	if err != nil {
+		l.Debug("search interrupted", "reason", err, "best", best, "lowerBound", st.LowerBound)
+	}
+	l.Debug("search finished",
+		"best", best,
+		"expanded", st.TasksExpanded,
+		"maxQueue", st.MaxQueueLen,
+		"duration", st.Duration)
+}
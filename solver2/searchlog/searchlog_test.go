@@ -0,0 +1,39 @@
+package searchlog
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Spi1y/tsp-solver/solver2/stats"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvery(t *testing.T) {
+	assert.Equal(t, DefaultEvery, Every(0))
+	assert.Equal(t, DefaultEvery, Every(-1))
+	assert.Equal(t, 10, Every(10))
+}
+
+func TestLog(t *testing.T) {
+	var b strings.Builder
+	l := slog.New(slog.NewJSONHandler(&b, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	Incumbent(l, 12, []types.Index{0, 2, 1, 0}, 5, time.Now())
+	Progress(l, 10, 3, 12, time.Now(), "lowerBound", types.Distance(7))
+	Finish(l, errors.New("canceled"), 12, stats.Stats{LowerBound: 7})
+
+	out := b.String()
+	assert.Contains(t, out, `"msg":"new incumbent","distance":12,"path":[0,2,1,0],"expanded":5`)
+	assert.Contains(t, out, `"msg":"search progress","expanded":10,"queue":3,"best":12,"lowerBound":7`)
+	assert.Contains(t, out, `"msg":"search interrupted","reason":"canceled"`)
+	assert.Contains(t, out, `"msg":"search finished"`)
+
+	// Nothing is logged without a logger
+	Incumbent(nil, 12, nil, 0, time.Now())
+	Progress(nil, 0, 0, 0, time.Now())
+	Finish(nil, nil, 0, stats.Stats{})
+}
@@ -1,97 +1,398 @@
 package solver2
 
 import (
+	"context"
 	"errors"
+	"log/slog"
+	"math"
+	"time"
 
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/metrics"
+	"github.com/Spi1y/tsp-solver/solver2/checkpoint"
 	"github.com/Spi1y/tsp-solver/solver2/iterator"
+	"github.com/Spi1y/tsp-solver/solver2/searchlog"
+	"github.com/Spi1y/tsp-solver/solver2/stats"
+	"github.com/Spi1y/tsp-solver/solver2/tail"
 	"github.com/Spi1y/tsp-solver/solver2/tasks"
 	"github.com/Spi1y/tsp-solver/solver2/types"
 )
 
+// DefaultLogEvery is the default number of expanded tasks between progress log records
+const DefaultLogEvery = searchlog.DefaultEvery
+
 // Solver is a TSP solver object. It is used to set a distance matrix and start
 // calculations
 type Solver struct {
+	// Tails with that many nodes left are solved with dynamic programming
+	// instead of branching. Use tail.ThresholdAuto to select it automatically
 	RecursiveThreshold types.Index
+	// Type of the tasks queue. Zero value means tasks.QueueHeap
+	Queue tasks.QueueType
+	// Strategy of choosing between tasks with equal estimates
+	Branching Branching
+	// Optional metrics collection, solves are not reported if it is nil
+	Metrics *metrics.Metrics
+	// Optional logger for debug events of the search
+	Logger *slog.Logger
+	// Search progress is logged every LogEvery expanded tasks.
+	// Zero value means DefaultLogEvery
+	LogEvery int
+	// Optional source of an external bound, which is checked between
+	// expanded tasks. Only solutions shorter than it are searched for
+	// afterwards, zero means no limit. It lets several searches share their
+	// solutions, e.g. distributed workers.
+	Bound func() types.Distance
+	// Optional callback, called on every solution the search improves on
+	OnIncumbent func(path []types.Index, distance types.Distance)
+	// Depots switches the objective to the longest route, if set. The tour
+	// is split into routes by the root node and the nodes marked as depots,
+	// and distances are lengths of the longest route instead of the tour
+	// length. Depots must have the same distances as the root node, as
+	// routes are built in one order only. Tails are not solved with dynamic
+	// programming then, as it minimizes the tour length. It is used by
+	// package mtsp.
+	Depots []bool
 
 	// Distance matrix
 	matrix [][]types.Distance
 	// Iterator (see package docs)
 	iterator *iterator.Iterator
 	// Tasks queue
-	taskQueue *tasks.Queue
-	// Temporary buffer to optimize normalization
-	buffer []types.Distance
+	taskQueue tasks.Queue
+	// Paths of all created tasks
+	tree tasks.Tree
+	// Path of the current task, the buffer is reused between tasks
+	path []types.Index
+	// Lower estimates of children, buffers are reused between tasks
+	bound bound
+	// DP solver for short tails of the path
+	tail tail.Solver
+	// Resolved recursive threshold
+	threshold types.Index
+	// Shortest distances from depots to nodes and back (see Depots)
+	fromDepot []types.Distance
+	toDepot   []types.Distance
 
 	// Current best solution
 	bestSolution         []types.Index
 	bestSolutionDistance types.Distance
+
+	// Search telemetry
+	stats    stats.Stats
+	start    time.Time
+	logEvery int
 }
 
 // Solve solves the TSP problem with a given distance matrix.
-func (s *Solver) Solve(m [][]types.Distance) ([]types.Index, types.Distance, error) {
+// Along with the solution it returns the search statistics.
+func (s *Solver) Solve(m [][]types.Distance) ([]types.Index, types.Distance, stats.Stats, error) {
+	return s.SolveContext(context.Background(), m)
+}
+
+// SolveContext is the same as Solve, but the search can be interrupted with
+// the context. In that case the best solution found so far is returned along
+// with the context error.
+func (s *Solver) SolveContext(ctx context.Context, m [][]types.Distance) ([]types.Index, types.Distance, stats.Stats, error) {
+	err := s.init(m)
+	if err != nil {
+		return nil, 0, stats.Stats{}, err
+	}
+
+	rootTask, err := s.attach(tasks.Task{
+		Path:     []types.Index{0},
+		Distance: 0,
+		Estimate: 0,
+	})
+	if err != nil {
+		return nil, 0, stats.Stats{}, err
+	}
+	s.insertTasks([]tasks.Task{rootTask})
+
+	return s.search(ctx)
+}
+
+// SolveFrom solves the subtree of the search starting with the given task.
+// Only solutions shorter than the bound are searched for, zero bound means
+// no limit. If there are no such solutions, an empty path is returned.
+func (s *Solver) SolveFrom(ctx context.Context, m [][]types.Distance, root tasks.Task, bound types.Distance) ([]types.Index, types.Distance, stats.Stats, error) {
+	err := s.init(m)
+	if err != nil {
+		return nil, 0, stats.Stats{}, err
+	}
+
+	if bound != 0 {
+		s.bestSolutionDistance = bound
+		s.taskQueue.TrimTail(bound)
+	}
+	root, err = s.attach(root)
+	if err != nil {
+		return nil, 0, stats.Stats{}, err
+	}
+	s.insertTasks([]tasks.Task{root})
+
+	return s.search(ctx)
+}
+
+// Checkpoint makes a snapshot of the last search state. Usually it is called
+// after SolveContext was interrupted, so the search can be continued later
+// with Resume.
+func (s *Solver) Checkpoint() (*checkpoint.Checkpoint, error) {
+	return checkpoint.Snapshot(s.matrix, s.taskQueue, s.bestSolution, s.bestSolutionDistance, s.stats, s.detach)
+}
+
+// Resume continues the search from the checkpoint. The matrix must be the same
+// as the one the checkpoint was made for. Statistics are accumulated over
+// all runs of the search.
+func (s *Solver) Resume(ctx context.Context, c *checkpoint.Checkpoint, m [][]types.Distance) ([]types.Index, types.Distance, stats.Stats, error) {
+	err := c.Check(m)
+	if err != nil {
+		return nil, 0, stats.Stats{}, err
+	}
+
+	err = s.init(m)
+	if err != nil {
+		return nil, 0, stats.Stats{}, err
+	}
+
+	s.stats, s.start, err = c.Restore(s.taskQueue, s.attach)
+	if err != nil {
+		return nil, 0, stats.Stats{}, err
+	}
+	if c.Distance != 0 {
+		s.bestSolution = c.Path
+		s.bestSolutionDistance = c.Distance
+		s.taskQueue.TrimTail(c.Distance)
+	}
+
+	return s.search(ctx)
+}
+
+// Split expands the search tree until there are at least count open tasks
+// and returns them in the order of estimates. These tasks are roots of
+// independent subtrees, which can be solved separately with SolveFrom.
+// A solution found while splitting is returned too.
+func (s *Solver) Split(m [][]types.Distance, count int) ([]tasks.Task, []types.Index, types.Distance, error) {
+	err := s.init(m)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	// Tails are not solved while splitting to keep subtrees open
+	s.threshold = 0
+	newTasks := make([]tasks.Task, len(m))
+
+	rootTask, err := s.attach(tasks.Task{
+		Path:     []types.Index{0},
+		Distance: 0,
+		Estimate: 0,
+	})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	s.insertTasks([]tasks.Task{rootTask})
+
+	for s.taskQueue.Len() < count {
+		task, err := s.taskQueue.PopFirst()
+		if err != nil {
+			break
+		}
+
+		created, err := s.solveTask(task, newTasks)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		s.tree.Release(task.Node)
+		s.insertTasks(newTasks[:created])
+	}
+
+	subtrees := make([]tasks.Task, 0, s.taskQueue.Len())
+	for task, err := s.taskQueue.PopFirst(); err == nil; task, err = s.taskQueue.PopFirst() {
+		subtrees = append(subtrees, s.detach(task))
+		s.tree.Release(task.Node)
+	}
+
+	return subtrees, s.bestSolution, s.bestSolutionDistance, nil
+}
+
+// init validates the distance matrix and resets the solver state
+func (s *Solver) init(m [][]types.Distance) error {
 	size := len(m)
 
 	if size == 0 {
-		return nil, 0, errors.New("Distance matrix is empty")
+		return errors.New("Distance matrix is empty")
 	}
 
-	for i := range m {
-		if len(m[i]) != size {
-			return nil, 0, errors.New("Distance matrix is not square")
-		}
+	err := s.Queue.Validate()
+	if err != nil {
+		return err
 	}
 
-	s.matrix = m
+	if (len(s.Depots) != 0) && (len(s.Depots) != size) {
+		return errors.New("Depots do not match the distance matrix")
+	}
+
+	// Matrix is copied to one backing array for better memory locality
+	fm, err := matrix.FromRows(m, math.MaxUint32)
+	if err != nil {
+		return err
+	}
+
+	s.start = time.Now()
+	s.stats = stats.Stats{}
+	s.logEvery = searchlog.Every(s.LogEvery)
+	s.matrix = fm.Rows()
 	s.bestSolution = []types.Index{}
 	s.bestSolutionDistance = 0
-	s.buffer = make([]types.Distance, size)
-	s.taskQueue = tasks.NewHeapQueue()
+	s.bound.init(size)
+	s.tree.Reset()
+	s.path = make([]types.Index, 0, size)
+	s.taskQueue = tasks.CreateQueue(s.Queue)
 	s.iterator = &iterator.Iterator{}
 	s.iterator.Init(types.Index(size))
+	s.threshold = tail.Threshold(s.RecursiveThreshold, size)
+	if len(s.Depots) != 0 {
+		s.threshold = 0
+		s.initRoutes()
+	}
 
-	newTasks := make([]tasks.Task, size)
+	return nil
+}
 
-	rootTask := tasks.Task{
-		Path:     []types.Index{0},
-		Distance: 0,
-		Estimate: 0,
-	}
-	s.taskQueue.Insert([]tasks.Task{rootTask})
+// search processes tasks from the queue until it is empty
+func (s *Solver) search(ctx context.Context) ([]types.Index, types.Distance, stats.Stats, error) {
+	newTasks := make([]tasks.Task, len(s.matrix))
 
+	done := ctx.Done()
+	s.applyBound()
 	for task, err := s.taskQueue.PopFirst(); err == nil; task, err = s.taskQueue.PopFirst() {
+		select {
+		case <-done:
+			// Returning the task back to keep the lower bound correct
+			s.taskQueue.InsertSingle(task)
+			s.finish(ctx.Err())
+			return s.bestSolution, s.bestSolutionDistance, s.stats, ctx.Err()
+		default:
+		}
+
+		s.stats.TasksExpanded++
 		count, err := s.solveTask(task, newTasks)
 		if err != nil {
-			return nil, 0, err
+			return nil, 0, stats.Stats{}, err
 		}
+		// Children keep the shared part of the path
+		s.tree.Release(task.Node)
+
+		s.insertTasks(newTasks[:count])
+		s.applyBound()
+		s.logProgress()
+	}
+
+	s.finish(nil)
+
+	return s.bestSolution, s.bestSolutionDistance, s.stats, nil
+}
 
-		s.taskQueue.Insert(newTasks[:count])
+// finish fills the statistics which are calculated at the end of the search
+// and reports the solve to metrics
+func (s *Solver) finish(err error) {
+	s.stats.LowerBound = s.bestSolutionDistance
+	if task, err := s.taskQueue.PeekFirst(); err == nil {
+		// Search was interrupted, lowest estimate of remaining tasks is the bound
+		s.stats.LowerBound = task.Estimate
 	}
 
-	return s.bestSolution, s.bestSolutionDistance, nil
+	trimmed := s.taskQueue.TrimmedLen()
+	s.stats.PrunedByTrim += trimmed
+	s.stats.Open = s.taskQueue.Len() - trimmed
+	s.stats.Duration = time.Since(s.start)
+
+	s.Metrics.ObserveSolve("solver2", len(s.matrix), s.bestSolutionDistance, s.stats, err)
+
+	searchlog.Finish(s.Logger, err, s.bestSolutionDistance, s.stats)
+}
+
+// logProgress periodically logs the state of the search
+func (s *Solver) logProgress() {
+	if s.Logger == nil || s.stats.TasksExpanded%s.logEvery != 0 {
+		return
+	}
+
+	lowerBound := s.bestSolutionDistance
+	if task, err := s.taskQueue.PeekFirst(); err == nil {
+		lowerBound = task.Estimate
+	}
+	searchlog.Progress(s.Logger, s.stats.TasksExpanded, s.taskQueue.Len(), s.bestSolutionDistance, s.start, "lowerBound", lowerBound)
+}
+
+// attach stores the path of a task passed in from outside in the tree
+func (s *Solver) attach(t tasks.Task) (tasks.Task, error) {
+	node, err := s.tree.AddPath(t.Path)
+	if err != nil {
+		return t, err
+	}
+
+	t.Node = node
+	t.Depth = types.Index(len(t.Path))
+	t.Path = nil
+
+	return t, nil
+}
+
+// detach restores the full path of a task passed out of the solver
+func (s *Solver) detach(t tasks.Task) tasks.Task {
+	t.Path = s.tree.Path(t.Node, nil)
+	t.Node = 0
+
+	return t
+}
+
+// insertTasks inserts new tasks into the queue, skipping ones that can not
+// improve the current best solution
+func (s *Solver) insertTasks(newTasks []tasks.Task) {
+	s.stats.TasksCreated += len(newTasks)
+
+	if s.bestSolutionDistance != 0 {
+		count := 0
+		for _, t := range newTasks {
+			if t.Estimate >= s.bestSolutionDistance {
+				s.stats.PrunedByBound++
+				s.tree.Release(t.Node)
+				continue
+			}
+			newTasks[count] = t
+			count++
+		}
+		newTasks = newTasks[:count]
+	}
+
+	s.taskQueue.Insert(newTasks)
+	s.stats.UpdateQueueLen(s.taskQueue.Len())
 }
 
 func (s *Solver) solveTask(t tasks.Task, newTasks []tasks.Task) (int, error) {
 	// TODO - try aggressive approach with full path first
 
-	err := s.iterator.SetPath(t.Path)
+	s.path = s.tree.Path(t.Node, s.path[:0])
+	err := s.iterator.SetPath(s.path)
 	if err != nil {
 		return 0, err
 	}
 	nextNodes := s.iterator.NodesToVisit()
 	rows := s.iterator.RowsToIterate()
 
-	currNode := t.Path[len(t.Path)-1]
+	currNode := s.path[len(s.path)-1]
 	nodesLeft := len(nextNodes)
 
-	if nodesLeft <= int(s.RecursiveThreshold) {
-		tailpath, taildistance := s.solveRecursively(currNode, nextNodes)
-		path := make([]types.Index, len(t.Path), len(t.Path)+len(tailpath))
-		copy(path, t.Path)
-		path = append(path, tailpath...)
-		distance := t.Distance + taildistance
+	var r routes
+	if len(s.Depots) != 0 {
+		r = s.routes(nextNodes)
+	}
 
-		s.newSolutionFound(path, distance)
-		newTasks = newTasks[:0]
+	if nodesLeft <= int(s.threshold) {
+		s.stats.TailCalls++
+		tailpath, taildistance := s.solveRecursively(currNode, nextNodes)
+		s.newSolutionFound(t.Node, tailpath, t.Distance+taildistance)
 		return 0, nil
 	}
 
@@ -100,98 +401,94 @@ func (s *Solver) solveTask(t tasks.Task, newTasks []tasks.Task) (int, error) {
 		// and notifying solver about found solution
 		finalNode := nextNodes[0]
 
-		path := make([]types.Index, len(t.Path), len(t.Path)+2)
-		copy(path, t.Path)
-		path = append(path, finalNode, 0)
 		distance := t.Distance + s.matrix[currNode][finalNode] + s.matrix[finalNode][0]
-		s.newSolutionFound(path, distance)
-		newTasks = newTasks[:0]
+		if len(s.Depots) != 0 {
+			distance = s.longestRoute(r, currNode, finalNode, t.Distance)
+		}
+		s.newSolutionFound(t.Node, []types.Index{finalNode, 0}, distance)
 		return 0, nil
 	}
 
-	newPathLen := len(t.Path) + 1
-	pathsSlice := make([]types.Index, nodesLeft*newPathLen)
+	s.bound.reset(s.matrix, rows)
 
 	for i, nextNode := range nextNodes {
-
-		var estimate types.Distance
-		cols, err := s.iterator.ColsToIterate(nextNode)
-		if err != nil {
-			return 0, err
+		if (len(s.Depots) != 0) && !s.canonical(r, currNode, nextNode) {
+			newTasks[i] = tasks.Task{Node: tasks.NoNode}
+			continue
 		}
 
-		for rowIndex, row := range rows {
-			rowSlice := s.matrix[row]
-
-			min := rowSlice[0]
-			var val types.Distance
-
-			// First pass to calculate row minimum
-			for _, col := range cols {
-				if row == col {
-					continue
-				}
-
-				val := rowSlice[col]
-				if min > val {
-					min = val
-				}
-			}
-			estimate += min
-
-			// Second pass to update column minimums in the buffer
-			if rowIndex == 0 {
-				// Fast path for a first row
-				for colIndex, col := range cols {
-					if row == col {
-						continue
-					}
-					// First row, minimum values are set without comparison
-					s.buffer[colIndex] = rowSlice[col] - min
-				}
-				continue
-			}
+		estimate := s.bound.estimate(i)
+		distance := t.Distance + s.matrix[currNode][nextNode]
 
-			// Normal path for other rows
-			for colIndex, col := range cols {
-				if row == col {
-					continue
-				}
-				val = rowSlice[col] - min
-
-				// Values are updated as needed
-				if s.buffer[colIndex] > val {
-					s.buffer[colIndex] = val
-				}
-			}
+		node, err := s.tree.Add(t.Node, nextNode)
+		if err != nil {
+			return 0, err
 		}
 
-		// Final pass on buffer to sum column minimums
-		for colIndex := range cols {
-			estimate += s.buffer[colIndex]
+		newTasks[i].Node = node
+		newTasks[i].Depth = t.Depth + 1
+		newTasks[i].Distance = distance
+		newTasks[i].Estimate = distance + estimate
+		if len(s.Depots) != 0 {
+			newTasks[i].Estimate = s.routeEstimate(r, nextNode, distance, distance+estimate)
 		}
+	}
+	s.rankChildren(newTasks[:nodesLeft], currNode, nextNodes)
 
-		path := pathsSlice[i*newPathLen : (i+1)*newPathLen]
-		copy(path, t.Path)
-		path[newPathLen-1] = nextNode
-
-		distance := t.Distance + s.matrix[currNode][nextNode]
+	if len(s.Depots) == 0 {
+		return nodesLeft, nil
+	}
 
-		newTasks[i].Path = path
-		newTasks[i].Distance = distance
-		newTasks[i].Estimate = distance + estimate
+	// Dropping permutations of routes
+	count := 0
+	for _, t := range newTasks[:nodesLeft] {
+		if t.Node != tasks.NoNode {
+			newTasks[count] = t
+			count++
+		}
 	}
 
-	return nodesLeft, nil
+	return count, nil
 }
 
-func (s *Solver) newSolutionFound(path []types.Index, distance types.Distance) {
+// newSolutionFound updates the best solution with the path of the task
+// followed by the tail. The full path is built only for improving solutions.
+func (s *Solver) newSolutionFound(node tasks.NodeID, tail []types.Index, distance types.Distance) {
 	if (s.bestSolutionDistance != 0) && (distance >= s.bestSolutionDistance) {
 		return
 	}
 
+	path := s.tree.Path(node, make([]types.Index, 0, s.tree.PathLen(node)+len(tail)))
+	path = append(path, tail...)
+
 	s.bestSolution = path
 	s.bestSolutionDistance = distance
+	s.stats.Incumbents = append(s.stats.Incumbents, stats.Incumbent{
+		Distance: distance,
+		Elapsed:  time.Since(s.start),
+	})
+	searchlog.Incumbent(s.Logger, distance, path, s.stats.TasksExpanded, s.start)
+	if s.OnIncumbent != nil {
+		s.OnIncumbent(path, distance)
+	}
 
 	s.taskQueue.TrimTail(distance)
 }
+
+// applyBound tightens the search with the external bound, if it is lower than
+// the best solution. The own solution is dropped then, as the one found
+// elsewhere is better.
+func (s *Solver) applyBound() {
+	if s.Bound == nil {
+		return
+	}
+
+	bound := s.Bound()
+	if (bound == 0) || ((s.bestSolutionDistance != 0) && (bound >= s.bestSolutionDistance)) {
+		return
+	}
+
+	s.bestSolution = []types.Index{}
+	s.bestSolutionDistance = bound
+	s.taskQueue.TrimTail(bound)
+}
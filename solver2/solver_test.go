@@ -1,8 +1,19 @@
 package solver2
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/Spi1y/tsp-solver/gen"
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/metrics"
+	"github.com/Spi1y/tsp-solver/solver2/checkpoint"
+	"github.com/Spi1y/tsp-solver/solver2/tasks"
 	"github.com/Spi1y/tsp-solver/solver2/types"
 	"github.com/stretchr/testify/assert"
 )
@@ -15,7 +26,7 @@ func TestSolverSolve(t *testing.T) {
 
 			for i := 0; i < 1+len(tt.distanceMatrix); i++ {
 				s.RecursiveThreshold = types.Index(i)
-				path, dist, err := s.Solve(tt.distanceMatrix)
+				path, dist, _, err := s.Solve(tt.distanceMatrix)
 
 				assert.Equal(t, tt.path, path)
 				assert.Equal(t, tt.dist, dist)
@@ -29,6 +40,300 @@ func TestSolverSolve(t *testing.T) {
 	}
 }
 
+func TestSolverSolveQueues(t *testing.T) {
+	tests := solverTestCases()
+	for _, qt := range tasks.QueueTypes {
+		for _, tt := range tests {
+			t.Run(qt.String()+"/"+tt.name, func(t *testing.T) {
+				s := &Solver{Queue: qt}
+				_, dist, st, err := s.Solve(tt.distanceMatrix)
+
+				assert.NoError(t, err)
+				assert.Equal(t, tt.dist, dist)
+				assert.Equal(t, st.TasksCreated, st.TasksExpanded+st.PrunedByBound+st.PrunedByTrim+st.Open)
+			})
+		}
+	}
+
+	s := &Solver{Queue: tasks.QueueType(42)}
+	_, _, _, err := s.Solve(tests[0].distanceMatrix)
+	assert.Error(t, err)
+}
+
+func BenchmarkSolverQueues(b *testing.B) {
+	m, err := gen.Generate(gen.Options{Kind: gen.Asymmetric, Size: 13, Seed: 1})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, qt := range tasks.QueueTypes {
+		b.Run(qt.String(), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				s := &Solver{Queue: qt}
+				s.Solve(m)
+			}
+		})
+	}
+}
+
+func TestSolverSolveStats(t *testing.T) {
+	tests := solverTestCases()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Solver{}
+			_, dist, st, err := s.Solve(tt.distanceMatrix)
+			assert.NoError(t, err)
+
+			// Every created task is either expanded or pruned
+			assert.Equal(t, st.TasksCreated, st.TasksExpanded+st.PrunedByBound+st.PrunedByTrim+st.Open)
+			assert.NotZero(t, st.MaxQueueLen)
+			assert.Equal(t, dist, st.LowerBound)
+			assert.Zero(t, st.TailCalls)
+			if assert.NotEmpty(t, st.Incumbents) {
+				assert.Equal(t, dist, st.Incumbents[len(st.Incumbents)-1].Distance)
+			}
+		})
+	}
+}
+
+func TestSolverTreeRelease(t *testing.T) {
+	m, err := gen.Generate(gen.Options{Kind: gen.Asymmetric, Size: 11, Seed: 1})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := &Solver{
+		Logger:   slog.New(&cancelHandler{cancel: cancel}),
+		LogEvery: 5,
+	}
+	_, _, st, err := s.SolveContext(ctx, m)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Empty(t, st.Incumbents)
+
+	// Only paths of open tasks are kept in the tree
+	for _, task := range s.taskQueue.Tasks() {
+		s.tree.Release(task.Node)
+	}
+	assert.Zero(t, s.tree.Len())
+
+	// Subtrees are passed out with full paths
+	_, _, _, err = s.Split(m, 20)
+	assert.NoError(t, err)
+	assert.Zero(t, s.tree.Len())
+}
+
+func TestSolverSolveCanceled(t *testing.T) {
+	tt := solveTestCase7Points()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := &Solver{Metrics: metrics.New()}
+	_, _, st, err := s.SolveContext(ctx, tt.distanceMatrix)
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.NotZero(t, st.Open)
+	assert.LessOrEqual(t, st.LowerBound, tt.dist)
+
+	var b strings.Builder
+	s.Metrics.WriteTo(&b)
+	assert.Contains(t, b.String(), `tsp_solve_cancellations_total{engine="solver2"} 1`)
+}
+
+func TestSolverSolveLogging(t *testing.T) {
+	tt := solveTestCase7Points()
+
+	var b strings.Builder
+	s := &Solver{
+		Logger:   slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		LogEvery: 1,
+	}
+	_, _, _, err := s.Solve(tt.distanceMatrix)
+	assert.NoError(t, err)
+
+	out := b.String()
+	assert.Contains(t, out, "msg=\"new incumbent\"")
+	assert.Contains(t, out, "msg=\"search progress\"")
+	assert.Contains(t, out, "msg=\"search finished\"")
+	assert.NotContains(t, out, "msg=\"search interrupted\"")
+}
+
+func TestSolverResume(t *testing.T) {
+	tests := solverTestCases()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Search is interrupted after a few expansions
+			s := &Solver{
+				Logger:   slog.New(&cancelHandler{cancel: cancel}),
+				LogEvery: 2,
+			}
+			_, _, _, err := s.SolveContext(ctx, tt.distanceMatrix)
+			c, cerr := s.Checkpoint()
+			assert.NoError(t, cerr)
+
+			name := filepath.Join(t.TempDir(), "solve.checkpoint")
+			assert.NoError(t, c.WriteFile(name))
+			c, cerr = checkpoint.ReadFile(name)
+			assert.NoError(t, cerr)
+
+			resumed := &Solver{}
+			path, dist, st, rerr := resumed.Resume(context.Background(), c, tt.distanceMatrix)
+			assert.NoError(t, rerr)
+			assert.Equal(t, tt.path, path)
+			assert.Equal(t, tt.dist, dist)
+			assert.Equal(t, st.TasksCreated, st.TasksExpanded+st.PrunedByBound+st.PrunedByTrim+st.Open)
+			if (err != nil) && (len(c.Tasks) != 0) {
+				assert.Greater(t, st.TasksExpanded, c.Stats.TasksExpanded)
+			}
+		})
+	}
+}
+
+func TestSolverResumeMismatch(t *testing.T) {
+	tt := solveTestCase7Points()
+	s := &Solver{}
+
+	_, err := s.Checkpoint()
+	assert.Error(t, err)
+
+	_, _, _, err = s.Solve(tt.distanceMatrix)
+	assert.NoError(t, err)
+	c, err := s.Checkpoint()
+	assert.NoError(t, err)
+
+	_, _, _, err = s.Resume(context.Background(), c, solveTestCase4Points().distanceMatrix)
+	assert.Equal(t, checkpoint.ErrMismatch, err)
+}
+
+// cancelHandler is a log handler, which cancels the search on progress records
+type cancelHandler struct {
+	cancel func()
+}
+
+func (h *cancelHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *cancelHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *cancelHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *cancelHandler) Handle(_ context.Context, r slog.Record) error {
+	if r.Message == "search progress" {
+		h.cancel()
+	}
+	return nil
+}
+
+func TestSolverSplit(t *testing.T) {
+	tests := solverTestCases()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Solver{}
+			subtrees, bestPath, bestDist, err := s.Split(tt.distanceMatrix, 5)
+			assert.NoError(t, err)
+
+			for i := 1; i < len(subtrees); i++ {
+				assert.LessOrEqual(t, subtrees[i-1].Estimate, subtrees[i].Estimate)
+			}
+
+			// Solving all subtrees independently gives the optimal solution
+			for _, subtree := range subtrees {
+				path, dist, _, err := s.SolveFrom(context.Background(), tt.distanceMatrix, subtree, bestDist)
+				assert.NoError(t, err)
+				if len(path) != 0 {
+					if bestDist != 0 {
+						assert.Less(t, dist, bestDist)
+					}
+					bestPath, bestDist = path, dist
+				}
+			}
+
+			// Several optimal solutions may exist, so only the distance is checked
+			assert.Len(t, bestPath, len(tt.path))
+			assert.Equal(t, tt.dist, bestDist)
+		})
+	}
+}
+
+func TestSolverSolveFromBound(t *testing.T) {
+	tt := solveTestCase7Points()
+	root := tasks.Task{Path: []types.Index{0}}
+	s := &Solver{}
+
+	path, dist, _, err := s.SolveFrom(context.Background(), tt.distanceMatrix, root, tt.dist+1)
+	assert.NoError(t, err)
+	assert.Equal(t, tt.path, path)
+	assert.Equal(t, tt.dist, dist)
+
+	path, _, _, err = s.SolveFrom(context.Background(), tt.distanceMatrix, root, tt.dist)
+	assert.NoError(t, err)
+	assert.Empty(t, path)
+}
+
+func TestSolverSolveExternalBound(t *testing.T) {
+	tt := solveTestCase7Points()
+
+	var incumbents []types.Distance
+	s := &Solver{
+		OnIncumbent: func(path []types.Index, distance types.Distance) {
+			assert.Len(t, path, len(tt.path))
+			incumbents = append(incumbents, distance)
+		},
+	}
+	_, _, _, err := s.Solve(tt.distanceMatrix)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, incumbents)
+	assert.Equal(t, tt.dist, incumbents[len(incumbents)-1])
+	for i := 1; i < len(incumbents); i++ {
+		assert.Less(t, incumbents[i], incumbents[i-1])
+	}
+
+	// Optimal solution is already known elsewhere, so nothing better is found
+	incumbents = nil
+	s.Bound = func() types.Distance { return tt.dist }
+	path, dist, _, err := s.Solve(tt.distanceMatrix)
+	assert.NoError(t, err)
+	assert.Empty(t, path)
+	assert.Equal(t, tt.dist, dist)
+	assert.Empty(t, incumbents)
+}
+
+func TestSolverSolveMatrix(t *testing.T) {
+	tt := solveTestCase4Points()
+	m, err := matrix.FromRows(tt.distanceMatrix, math.MaxUint32)
+	assert.NoError(t, err)
+
+	s := &Solver{}
+	path, dist, _, err := s.SolveMatrix(context.Background(), m)
+	assert.NoError(t, err)
+	assert.Equal(t, tt.path, path)
+	assert.Equal(t, tt.dist, dist)
+
+	// Forbidding an edge of the optimal tour leads to another one
+	m.Forbid(int(tt.path[1]), int(tt.path[2]))
+	path, dist, _, err = s.SolveMatrix(context.Background(), m)
+	assert.NoError(t, err)
+	assert.Greater(t, dist, tt.dist)
+	for i := 1; i < len(path); i++ {
+		assert.False(t, m.Forbidden(int(path[i-1]), int(path[i])))
+	}
+
+	// All edges from the root are forbidden
+	for j := 1; j < m.Size(); j++ {
+		m.Forbid(0, j)
+	}
+	_, _, _, err = s.SolveMatrix(context.Background(), m)
+	assert.Equal(t, ErrNoTour, err)
+
+	huge := matrix.New[types.Distance](3, math.MaxUint32)
+	huge.Set(0, 1, math.MaxUint32/4)
+	_, _, _, err = s.SolveMatrix(context.Background(), huge)
+	assert.Error(t, err)
+
+	_, _, _, err = s.SolveMatrix(context.Background(), matrix.New[types.Distance](0, math.MaxUint32))
+	assert.Error(t, err)
+}
+
 func solverTestCases() []*solveTestCase {
 	result := []*solveTestCase{}
 	result = append(result, solveTestCase2Points())
@@ -0,0 +1,49 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Stats holds search telemetry collected during a single Solve call
+type Stats struct {
+	// Tasks generated by branching, including the root task
+	TasksCreated int
+	// Tasks popped from the queue and processed
+	TasksExpanded int
+	// New tasks dropped right away because their estimate was not better
+	// than the current best solution
+	PrunedByBound int
+	// Tasks left in the queue after the search, cut off by TrimTail
+	PrunedByTrim int
+	// Tasks left unexplored because the search was interrupted
+	Open int
+	// Maximum length of the tasks queue
+	MaxQueueLen int
+	// Tails solved with dynamic programming (see tail package)
+	TailCalls int
+	// History of best solution improvements
+	Incumbents []Incumbent
+	// Time spent by each worker processing tasks. Filled by parallel solvers only
+	WorkerBusy []time.Duration
+	// Lowest possible distance of the optimal solution. Equals to the solution
+	// distance if the search was completed, and is lower if it was interrupted
+	LowerBound types.Distance
+	// Total time of the search
+	Duration time.Duration
+}
+
+// Incumbent is a record of the best solution improvement
+type Incumbent struct {
+	Distance types.Distance
+	// Time passed since the start of the search
+	Elapsed time.Duration
+}
+
+// UpdateQueueLen updates the maximum queue length
+func (s *Stats) UpdateQueueLen(l int) {
+	if s.MaxQueueLen < l {
+		s.MaxQueueLen = l
+	}
+}
@@ -0,0 +1,132 @@
+package tail
+
+import (
+	"math"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// MaxSize is the maximum number of nodes the tail solver accepts.
+// Memory consumption grows as 2^n * n, so larger thresholds are clamped to it.
+const MaxSize = 16
+
+// ThresholdAuto is a special threshold value, which tells the solver to choose
+// the recursive threshold automatically based on the matrix size
+const ThresholdAuto types.Index = math.MaxUint8
+
+// Threshold used for matrices too big to be solved by DP as a whole.
+// On smaller matrices DP from the root task outperforms branching
+const autoThreshold = 12
+
+// Threshold resolves the recursive threshold requested by the user
+// for a matrix of a given size
+func Threshold(threshold types.Index, size int) types.Index {
+	if threshold == ThresholdAuto {
+		if size-1 <= MaxSize {
+			return types.Index(size)
+		}
+		return autoThreshold
+	}
+
+	if threshold > MaxSize {
+		return MaxSize
+	}
+
+	return threshold
+}
+
+// Solver calculates the shortest path from the current node through all given
+// nodes and back to the root node (index 0) using Held-Karp bitmask
+// dynamic programming. Internal buffers are reused between calls, so
+// a Solver must not be used concurrently.
+type Solver struct {
+	// cost[mask*k+j] is the shortest distance from the node j through
+	// all nodes in the mask (j is not in it) and back to the root node
+	cost []types.Distance
+}
+
+// Solve returns the optimal tail path (finishing with the root node)
+// and its distance. If nodes are sorted in ascending order, the
+// lexicographically smallest path is returned among several optimal ones.
+func (s *Solver) Solve(m [][]types.Distance, currNode types.Index, nodes []types.Index) ([]types.Index, types.Distance) {
+	k := len(nodes)
+	if k == 0 {
+		return []types.Index{0}, m[currNode][0]
+	}
+
+	full := 1 << k
+	s.resize(full * k)
+
+	for mask := 0; mask < full; mask++ {
+		for j := 0; j < k; j++ {
+			if mask&(1<<j) != 0 {
+				continue
+			}
+
+			s.cost[mask*k+j] = s.bestStep(m, nodes[j], nodes, mask)
+		}
+	}
+
+	path := make([]types.Index, 0, k+1)
+	bestDistance := s.bestStep(m, currNode, nodes, full-1)
+
+	// Restoring the path. Nodes are checked in the given order,
+	// so the first one leading to the optimal distance wins
+	curr := currNode
+	remaining := bestDistance
+	for mask := full - 1; mask != 0; {
+		for i := 0; i < k; i++ {
+			bit := 1 << i
+			if mask&bit == 0 {
+				continue
+			}
+
+			rest := s.cost[(mask^bit)*k+i]
+			if m[curr][nodes[i]]+rest == remaining {
+				curr = nodes[i]
+				remaining = rest
+				mask ^= bit
+				path = append(path, curr)
+				break
+			}
+		}
+	}
+	path = append(path, 0)
+
+	return path, bestDistance
+}
+
+// bestStep calculates the shortest distance from the node through all nodes
+// in the mask and back to the root node
+func (s *Solver) bestStep(m [][]types.Distance, from types.Index, nodes []types.Index, mask int) types.Distance {
+	if mask == 0 {
+		return m[from][0]
+	}
+
+	k := len(nodes)
+	row := m[from]
+	first := true
+	var best types.Distance
+	for i := 0; i < k; i++ {
+		bit := 1 << i
+		if mask&bit == 0 {
+			continue
+		}
+
+		dist := row[nodes[i]] + s.cost[(mask^bit)*k+i]
+		if first || (best > dist) {
+			best = dist
+			first = false
+		}
+	}
+
+	return best
+}
+
+func (s *Solver) resize(size int) {
+	if cap(s.cost) < size {
+		s.cost = make([]types.Distance, size)
+	}
+
+	s.cost = s.cost[:size]
+}
@@ -0,0 +1,105 @@
+package tail
+
+import (
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolver_Solve(t *testing.T) {
+	m := [][]types.Distance{
+		{0, 15147, 21742, 12730, 18594, 6147, 6955, 10000},
+		{17465, 0, 30524, 22534, 27376, 20763, 15326, 21214},
+		{23594, 43627, 0, 16165, 9604, 21957, 18560, 21180},
+		{11103, 22595, 16255, 0, 10210, 5909, 7880, 3274},
+		{19133, 27796, 9754, 10054, 0, 12856, 14099, 10486},
+		{6155, 21069, 23218, 7694, 14520, 0, 5419, 4964},
+		{5736, 14952, 18081, 8492, 14933, 6300, 0, 7172},
+		{10801, 21605, 17131, 4504, 11197, 3615, 6890, 0},
+	}
+
+	tests := []struct {
+		name  string
+		curr  types.Index
+		nodes []types.Index
+		path  []types.Index
+		dist  types.Distance
+	}{
+		{
+			"no nodes left",
+			5, []types.Index{},
+			[]types.Index{0}, 6155,
+		},
+		{
+			"single node",
+			5, []types.Index{3},
+			[]types.Index{3, 0}, 7694 + 11103,
+		},
+		{
+			"two nodes",
+			5, []types.Index{3, 7},
+			[]types.Index{7, 3, 0}, 4964 + 4504 + 11103,
+		},
+		{
+			"full tail",
+			0, []types.Index{1, 2, 3, 4, 5, 6, 7},
+			[]types.Index{1, 6, 2, 4, 3, 7, 5, 0}, 81_256,
+		},
+		{
+			"partial tail",
+			6, []types.Index{2, 3, 4, 5, 7},
+			[]types.Index{2, 4, 3, 7, 5, 0}, 81_256 - 15147 - 15326,
+		},
+	}
+
+	s := &Solver{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, dist := s.Solve(m, tt.curr, tt.nodes)
+
+			assert.Equal(t, tt.path, path)
+			assert.Equal(t, tt.dist, dist)
+		})
+	}
+}
+
+func TestSolver_SolveTies(t *testing.T) {
+	m := [][]types.Distance{
+		{0, 1, 1, 5, 9},
+		{9, 0, 5, 1, 1},
+		{1, 9, 0, 1, 5},
+		{5, 1, 1, 9, 1},
+		{1, 5, 9, 1, 0},
+	}
+
+	// Both 0-1-4-3-2-0 and 0-2-3-1-4-0 are optimal, lexicographically
+	// smallest one is expected
+	s := &Solver{}
+	path, dist := s.Solve(m, 0, []types.Index{1, 2, 3, 4})
+
+	assert.Equal(t, []types.Index{1, 4, 3, 2, 0}, path)
+	assert.Equal(t, types.Distance(5), dist)
+}
+
+func TestThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold types.Index
+		size      int
+		want      types.Index
+	}{
+		{"disabled", 0, 10, 0},
+		{"explicit", 5, 10, 5},
+		{"clamped", MaxSize + 1, 30, MaxSize},
+		{"auto small matrix", ThresholdAuto, 8, 8},
+		{"auto full DP", ThresholdAuto, MaxSize + 1, MaxSize + 1},
+		{"auto big matrix", ThresholdAuto, 20, autoThreshold},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Threshold(tt.threshold, tt.size))
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package tasks
+
+import (
+	"fmt"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Bucket implements tasks queue as buckets of tasks with equal estimates
+// and ranks. Distinct keys are kept in a binary heap, so with many equal keys
+// most insertions and removals are O(1). Tasks of the same bucket are popped
+// in LIFO order, so recently created and usually deeper tasks go first.
+type Bucket struct {
+	trim
+
+	buckets map[bucketKey][]Task
+	// Binary min heap of keys with non-empty buckets
+	keys []bucketKey
+	// Emptied buckets, kept to reuse their memory
+	free [][]Task
+	len  int
+}
+
+// bucketKey is the priority shared by tasks of a bucket
+type bucketKey struct {
+	estimate types.Distance
+	rank     int64
+}
+
+// less orders keys the same way as tasks
+func (k bucketKey) less(other bucketKey) bool {
+	if k.estimate != other.estimate {
+		return k.estimate < other.estimate
+	}
+
+	return k.rank < other.rank
+}
+
+// NewBucketQueue creates and returns new bucket queue
+func NewBucketQueue() *Bucket {
+	return &Bucket{
+		buckets: make(map[bucketKey][]Task),
+	}
+}
+
+// Len returns number of tasks in the queue
+func (q *Bucket) Len() int { return q.len }
+
+// Insert inserts several records to the queue
+func (q *Bucket) Insert(tasks []Task) {
+	for _, task := range tasks {
+		q.InsertSingle(task)
+	}
+}
+
+// InsertSingle inserts single record to the queue
+func (q *Bucket) InsertSingle(task Task) {
+	q.len++
+
+	key := bucketKey{task.Estimate, task.Rank}
+	bucket, ok := q.buckets[key]
+	if !ok {
+		if n := len(q.free); n > 0 {
+			bucket = q.free[n-1]
+			q.free = q.free[:n-1]
+		}
+		q.pushKey(key)
+	}
+	q.buckets[key] = append(bucket, task)
+}
+
+// IsEmpty checks if there is no records in the queue.
+func (q *Bucket) IsEmpty() bool {
+	return (len(q.keys) == 0) || (q.trimSet && (q.keys[0].estimate >= q.trimValue))
+}
+
+// PopFirst gets the task with the lowest estimate and removes it from the queue
+func (q *Bucket) PopFirst() (Task, error) {
+	if q.IsEmpty() {
+		return Task{}, fmt.Errorf("Queue is empty")
+	}
+
+	key := q.keys[0]
+	bucket := q.buckets[key]
+	n := len(bucket) - 1
+	task := bucket[n]
+	bucket[n] = Task{}
+	q.len--
+
+	if n == 0 {
+		delete(q.buckets, key)
+		q.free = append(q.free, bucket[:0])
+		q.popKey()
+	} else {
+		q.buckets[key] = bucket[:n]
+	}
+
+	return task, nil
+}
+
+// PeekFirst gets the task with the lowest estimate without removing it
+func (q *Bucket) PeekFirst() (Task, error) {
+	if q.IsEmpty() {
+		return Task{}, fmt.Errorf("Queue is empty")
+	}
+
+	bucket := q.buckets[q.keys[0]]
+	return bucket[len(bucket)-1], nil
+}
+
+// TrimmedLen counts records which are cut off by TrimTail, but are still
+// physically stored in the queue.
+func (q *Bucket) TrimmedLen() int {
+	if !q.trimSet {
+		return 0
+	}
+
+	count := 0
+	for key, bucket := range q.buckets {
+		if key.estimate >= q.trimValue {
+			count += len(bucket)
+		}
+	}
+
+	return count
+}
+
+// Tasks returns records which are not cut off by TrimTail in no particular
+// order. Returned slice is a copy, paths are shared with the queue.
+func (q *Bucket) Tasks() []Task {
+	result := make([]Task, 0, q.len)
+	for key, bucket := range q.buckets {
+		if q.trimSet && (key.estimate >= q.trimValue) {
+			continue
+		}
+		result = append(result, bucket...)
+	}
+
+	return result
+}
+
+// String implements the Stringer interface
+// Used mainly for testing
+func (q *Bucket) String() string {
+	all := make([]Task, 0, q.len)
+	for _, bucket := range q.buckets {
+		all = append(all, bucket...)
+	}
+
+	return format("Bucket", all)
+}
+
+// pushKey adds a key to the heap of keys
+func (q *Bucket) pushKey(key bucketKey) {
+	q.keys = append(q.keys, key)
+
+	i := len(q.keys) - 1
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !q.keys[i].less(q.keys[parent]) {
+			break
+		}
+		q.keys[i], q.keys[parent] = q.keys[parent], q.keys[i]
+		i = parent
+	}
+}
+
+// popKey removes the lowest key from the heap of keys
+func (q *Bucket) popKey() {
+	n := len(q.keys) - 1
+	q.keys[0] = q.keys[n]
+	q.keys = q.keys[:n]
+
+	i := 0
+	for {
+		child := 2*i + 1
+		if child >= n {
+			break
+		}
+		if (child+1 < n) && q.keys[child+1].less(q.keys[child]) {
+			child++
+		}
+		if !q.keys[child].less(q.keys[i]) {
+			break
+		}
+		q.keys[i], q.keys[child] = q.keys[child], q.keys[i]
+		i = child
+	}
+}
@@ -0,0 +1,140 @@
+package tasks
+
+import "fmt"
+
+// DAryHeap implements tasks queue based on d-ary heap. Wider nodes make
+// the heap shallower, so insertions are cheaper and the memory access
+// pattern is friendlier to the cache, at the cost of more comparisons
+// on removal.
+type DAryHeap struct {
+	trim
+
+	slice []Task
+	arity int
+	// Longer paths are preferred on equal estimates, before ranks
+	preferDeep bool
+}
+
+// NewDAryQueue creates and returns new d-ary heap queue with a given arity
+func NewDAryQueue(arity int) *DAryHeap {
+	if arity < 2 {
+		arity = 2
+	}
+
+	return &DAryHeap{arity: arity}
+}
+
+// NewDepthQueue creates and returns new binary heap queue, which pops tasks
+// with longer paths first on equal estimates. It dives deeper into the
+// search tree and finds complete solutions earlier.
+func NewDepthQueue() *DAryHeap {
+	return &DAryHeap{arity: 2, preferDeep: true}
+}
+
+// Len returns len of the heap
+func (h *DAryHeap) Len() int { return len(h.slice) }
+
+// less is a comparison function of the heap
+func (h *DAryHeap) less(i, j int) bool {
+	a, b := &h.slice[i], &h.slice[j]
+	if h.preferDeep && (a.Estimate == b.Estimate) && (a.Depth != b.Depth) {
+		return a.Depth > b.Depth
+	}
+
+	return before(a, b)
+}
+
+// Insert inserts several records to the queue
+func (h *DAryHeap) Insert(tasks []Task) {
+	for _, task := range tasks {
+		h.InsertSingle(task)
+	}
+}
+
+// InsertSingle inserts single record to the queue
+func (h *DAryHeap) InsertSingle(task Task) {
+	h.slice = append(h.slice, task)
+
+	// Sifting the new element up
+	i := len(h.slice) - 1
+	for i > 0 {
+		parent := (i - 1) / h.arity
+		if !h.less(i, parent) {
+			break
+		}
+		h.slice[i], h.slice[parent] = h.slice[parent], h.slice[i]
+		i = parent
+	}
+}
+
+// IsEmpty checks if there is no records in the heap.
+func (h *DAryHeap) IsEmpty() bool {
+	return (len(h.slice) == 0) || h.cut(h.slice[0])
+}
+
+// PopFirst gets the task with the lowest estimate and removes it from the heap
+func (h *DAryHeap) PopFirst() (Task, error) {
+	if h.IsEmpty() {
+		return Task{}, fmt.Errorf("Queue is empty")
+	}
+
+	n := len(h.slice) - 1
+	h.slice[0], h.slice[n] = h.slice[n], h.slice[0]
+
+	// Sifting the moved element down
+	i := 0
+	for {
+		first := h.arity*i + 1
+		if first >= n {
+			break
+		}
+
+		best := first
+		last := first + h.arity
+		if last > n {
+			last = n
+		}
+		for child := first + 1; child < last; child++ {
+			if h.less(child, best) {
+				best = child
+			}
+		}
+
+		if !h.less(best, i) {
+			break
+		}
+		h.slice[i], h.slice[best] = h.slice[best], h.slice[i]
+		i = best
+	}
+
+	task := h.slice[n]
+	h.slice = h.slice[:n]
+	return task, nil
+}
+
+// PeekFirst gets the task with the lowest estimate without removing it
+func (h *DAryHeap) PeekFirst() (Task, error) {
+	if h.IsEmpty() {
+		return Task{}, fmt.Errorf("Queue is empty")
+	}
+
+	return h.slice[0], nil
+}
+
+// TrimmedLen counts records which are cut off by TrimTail, but are still
+// physically stored in the heap.
+func (h *DAryHeap) TrimmedLen() int {
+	return h.trimmedLen(h.slice)
+}
+
+// Tasks returns records which are not cut off by TrimTail in no particular
+// order. Returned slice is a copy, paths are shared with the queue.
+func (h *DAryHeap) Tasks() []Task {
+	return h.uncut(h.slice)
+}
+
+// String implements the Stringer interface
+// Used mainly for testing
+func (h *DAryHeap) String() string {
+	return format("DAryHeap", h.slice)
+}
@@ -1,97 +1,112 @@
 package tasks
 
 import (
-	"container/heap"
 	"fmt"
 	"strings"
-
-	"github.com/Spi1y/tsp-solver/solver2/types"
 )
 
-// Queue implements tasks queue based on binary heap
-type Queue struct {
-	slice []Task
+// Heap implements tasks queue based on binary heap
+type Heap struct {
+	trim
 
-	trimSet   bool
-	trimValue types.Distance
+	slice []Task
 }
 
 // Len returns len of the heap
-func (h *Queue) Len() int { return len(h.slice) }
+func (h *Heap) Len() int { return len(h.slice) }
 
-// Less is a comparison function, required for heap.interface
-func (h *Queue) Less(i, j int) bool {
-	// We have a minHeap, which means top record is a record with a lowest distance
-	return h.slice[i].Estimate < h.slice[j].Estimate
+// less reports if the task i goes before the task j. It is a min-heap,
+// so the top task is the one with the lowest estimate.
+func (h *Heap) less(i, j int) bool {
+	return before(&h.slice[i], &h.slice[j])
 }
 
-// Swap swaps elements, required for heap.interface
-func (h *Queue) Swap(i, j int) {
+func (h *Heap) swap(i, j int) {
 	h.slice[i], h.slice[j] = h.slice[j], h.slice[i]
 }
 
-// Push pushes a new element to the heap, required for heap.interface
-func (h *Queue) Push(x interface{}) {
-	item := x.(Task)
-	h.slice = append(h.slice, item)
+// push adds the task to the heap, sifting it up to its place
+func (h *Heap) push(task Task) {
+	h.slice = append(h.slice, task)
+
+	// Sifting the new element up
+	i := len(h.slice) - 1
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !h.less(i, parent) {
+			break
+		}
+		h.swap(i, parent)
+		i = parent
+	}
 }
 
-// Pop pops a top element from the heap deleting it. Required for heap.interface
-func (h *Queue) Pop() interface{} {
-	old := h.slice
-	n := len(old)
-	item := old[n-1]
-	h.slice = old[0 : n-1]
-	return item
+// pop removes the top task, moving the last one down to its place
+func (h *Heap) pop() Task {
+	n := len(h.slice) - 1
+	h.swap(0, n)
+
+	// Sifting the moved element down
+	i := 0
+	for {
+		child := 2*i + 1
+		if child >= n {
+			break
+		}
+		if (child+1 < n) && h.less(child+1, child) {
+			child++
+		}
+		if !h.less(child, i) {
+			break
+		}
+		h.swap(i, child)
+		i = child
+	}
+
+	task := h.slice[n]
+	h.slice = h.slice[:n]
+	return task
 }
 
 // NewHeapQueue creates and returns new heap queue
-func NewHeapQueue() *Queue {
-	h := &Queue{
+func NewHeapQueue() *Heap {
+	h := &Heap{
 		slice: nil,
-
-		trimValue: 0,
-		trimSet:   false,
 	}
 
 	return h
 }
 
 // Insert inserts several records to the queue
-func (h *Queue) Insert(tasks []Task) {
+func (h *Heap) Insert(tasks []Task) {
 	// A quick path for an empty insertion
 	if len(tasks) == 0 {
 		return
 	}
 
 	for _, task := range tasks {
-		heap.Push(h, task)
+		h.push(task)
 	}
 }
 
 // InsertSingle inserts single record to the queue
-func (h *Queue) InsertSingle(task Task) {
-	heap.Push(h, task)
+func (h *Heap) InsertSingle(task Task) {
+	h.push(task)
 }
 
-// TrimTail should trim records from the tail of the queue with a distance greater
-// than the given argument. However, it is very costly to do so with the heap, so for
-// now, we remember the trimming value and use it in PopFirst and IsEmpty to determine
-// if the queue should be empty.
-func (h *Queue) TrimTail(distance types.Distance) {
-	if !h.trimSet || (h.trimValue > distance) {
-		h.trimSet = true
-		h.trimValue = distance
-	}
+// TrimmedLen counts records which are cut off by TrimTail, but are still
+// physically stored in the heap.
+func (h *Heap) TrimmedLen() int {
+	return h.trimmedLen(h.slice)
 }
 
 // IsEmpty checks if there is no records in the list.
-func (h *Queue) IsEmpty() bool {
+func (h *Heap) IsEmpty() bool {
 	if len(h.slice) == 0 {
 		return true
 	}
 
-	if h.trimSet && (h.slice[0].Estimate >= h.trimValue) {
+	if h.cut(h.slice[0]) {
 		return true
 	}
 
@@ -101,18 +116,33 @@ func (h *Queue) IsEmpty() bool {
 // PopFirst gets the task from the first record in the list and
 // removes it from the list.
 // If list is empty, it returns nil.
-func (h *Queue) PopFirst() (Task, error) {
+func (h *Heap) PopFirst() (Task, error) {
 	if h.IsEmpty() {
 		return Task{}, fmt.Errorf("Queue is empty")
 	}
 
-	task := heap.Pop(h).(Task)
-	return task, nil
+	return h.pop(), nil
+}
+
+// PeekFirst gets the task from the first record in the list
+// without removing it.
+func (h *Heap) PeekFirst() (Task, error) {
+	if h.IsEmpty() {
+		return Task{}, fmt.Errorf("Queue is empty")
+	}
+
+	return h.slice[0], nil
+}
+
+// Tasks returns records which are not cut off by TrimTail in no particular
+// order. Returned slice is a copy, paths are shared with the queue.
+func (h *Heap) Tasks() []Task {
+	return h.uncut(h.slice)
 }
 
 // String implements the Stringer interface
 // Used mainly for testing
-func (h *Queue) String() string {
+func (h *Heap) String() string {
 	var b strings.Builder
 
 	duplicate := NewHeapQueue()
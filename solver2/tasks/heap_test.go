@@ -61,3 +61,41 @@ func TestHeap_Insert(t *testing.T) {
 		})
 	}
 }
+
+func TestHeap_PeekFirst(t *testing.T) {
+	list := NewHeapQueue()
+
+	_, err := list.PeekFirst()
+	assert.Error(t, err)
+
+	list.Insert([]Task{{Estimate: 7}, {Estimate: 3}, {Estimate: 5}})
+	task, err := list.PeekFirst()
+	assert.NoError(t, err)
+	assert.Equal(t, types.Distance(3), task.Estimate)
+	assert.Equal(t, 3, list.Len())
+
+	list.TrimTail(3)
+	_, err = list.PeekFirst()
+	assert.Error(t, err)
+}
+
+func TestHeap_TrimmedLen(t *testing.T) {
+	list := NewHeapQueue()
+	list.Insert([]Task{{Estimate: 7}, {Estimate: 3}, {Estimate: 5}, {Estimate: 1}})
+	assert.Equal(t, 0, list.TrimmedLen())
+
+	list.TrimTail(5)
+	assert.Equal(t, 2, list.TrimmedLen())
+	assert.Equal(t, 4, list.Len())
+}
+
+func TestHeap_Tasks(t *testing.T) {
+	list := NewHeapQueue()
+	assert.Empty(t, list.Tasks())
+
+	list.Insert([]Task{{Estimate: 7}, {Estimate: 3}, {Estimate: 5}, {Estimate: 1}})
+	assert.ElementsMatch(t, []Task{{Estimate: 1}, {Estimate: 3}, {Estimate: 5}, {Estimate: 7}}, list.Tasks())
+
+	list.TrimTail(5)
+	assert.ElementsMatch(t, []Task{{Estimate: 1}, {Estimate: 3}}, list.Tasks())
+}
@@ -0,0 +1,161 @@
+package tasks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Queue is an interface to represent different priority queues of tasks.
+// Tasks with lower estimates are popped first.
+type Queue interface {
+	fmt.Stringer
+
+	Len() int
+	Insert(tasks []Task)
+	InsertSingle(task Task)
+	PopFirst() (Task, error)
+	PeekFirst() (Task, error)
+
+	TrimTail(distance types.Distance)
+	TrimmedLen() int
+	IsEmpty() bool
+	Tasks() []Task
+}
+
+// QueueType is a container type for a different types of queues
+type QueueType int
+
+const (
+	// QueueHeap is binary heap queue
+	QueueHeap QueueType = iota
+	// QueueBucket is bucket queue keyed by estimates
+	QueueBucket
+	// QueuePairing is pairing heap queue
+	QueuePairing
+	// QueueDAry is 4-ary heap queue
+	QueueDAry
+	// QueueDepth is binary heap queue, which prefers longer paths
+	// on equal estimates
+	QueueDepth
+)
+
+// QueueTypes lists all types of queues
+var QueueTypes = []QueueType{QueueHeap, QueueBucket, QueuePairing, QueueDAry, QueueDepth}
+
+// String implements the Stringer interface
+func (t QueueType) String() string {
+	switch t {
+	case QueueHeap:
+		return "heap"
+	case QueueBucket:
+		return "bucket"
+	case QueuePairing:
+		return "pairing"
+	case QueueDAry:
+		return "dary"
+	case QueueDepth:
+		return "depth"
+	}
+
+	return fmt.Sprintf("QueueType(%d)", int(t))
+}
+
+// Validate returns an error for unknown queue types
+func (t QueueType) Validate() error {
+	for _, known := range QueueTypes {
+		if t == known {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Unknown queue type %v", t)
+}
+
+// CreateQueue creates and returns a queue of a requested type.
+// Unknown types, rejected by Validate, result in nil.
+func CreateQueue(t QueueType) Queue {
+	switch t {
+	case QueueHeap:
+		return NewHeapQueue()
+	case QueueBucket:
+		return NewBucketQueue()
+	case QueuePairing:
+		return NewPairingQueue()
+	case QueueDAry:
+		return NewDAryQueue(4)
+	case QueueDepth:
+		return NewDepthQueue()
+	}
+
+	return nil
+}
+
+// trim implements TrimTail for all queues. It is very costly to remove
+// records from the middle of most queues, so the trimming value is remembered
+// and used in PopFirst and IsEmpty to determine if the queue should be empty.
+type trim struct {
+	trimSet   bool
+	trimValue types.Distance
+}
+
+// TrimTail should trim records from the tail of the queue with an estimate
+// greater than or equal to the given argument.
+func (t *trim) TrimTail(distance types.Distance) {
+	if !t.trimSet || (t.trimValue > distance) {
+		t.trimSet = true
+		t.trimValue = distance
+	}
+}
+
+// cut checks if the task is cut off by TrimTail
+func (t *trim) cut(task Task) bool {
+	return t.trimSet && (task.Estimate >= t.trimValue)
+}
+
+// trimmedLen counts the tasks cut off by TrimTail
+func (t *trim) trimmedLen(tasks []Task) int {
+	if !t.trimSet {
+		return 0
+	}
+
+	count := 0
+	for i := range tasks {
+		if t.cut(tasks[i]) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// uncut returns a copy of the tasks, which are not cut off by TrimTail
+func (t *trim) uncut(tasks []Task) []Task {
+	result := make([]Task, 0, len(tasks))
+	for _, task := range tasks {
+		if t.cut(task) {
+			continue
+		}
+		result = append(result, task)
+	}
+
+	return result
+}
+
+// format prints distances of the tasks in the order of estimates.
+// Used mainly for testing
+func format(name string, tasks []Task) string {
+	sorted := append([]Task(nil), tasks...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Estimate < sorted[j].Estimate
+	})
+
+	var b strings.Builder
+	for _, task := range sorted {
+		fmt.Fprintf(&b, " %d", task.Distance)
+	}
+
+	return fmt.Sprintf("tasks.%s:%s", name, b.String())
+}
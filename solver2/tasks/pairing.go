@@ -0,0 +1,173 @@
+package tasks
+
+import "fmt"
+
+// pairingNode is a node of the pairing heap. Children of a node are kept
+// in a singly linked list.
+type pairingNode struct {
+	task    Task
+	child   *pairingNode
+	sibling *pairingNode
+}
+
+// Pairing implements tasks queue based on pairing heap. Insertions are O(1),
+// removals are amortized O(log n). Removed nodes are kept in a free list and
+// reused, so a steady search does not allocate.
+type Pairing struct {
+	trim
+
+	root *pairingNode
+	len  int
+	free *pairingNode
+	// Temporary buffer for merging children of the removed root
+	pairs []*pairingNode
+}
+
+// NewPairingQueue creates and returns new pairing heap queue
+func NewPairingQueue() *Pairing {
+	return &Pairing{}
+}
+
+// Len returns number of tasks in the heap
+func (h *Pairing) Len() int { return h.len }
+
+// Insert inserts several records to the queue
+func (h *Pairing) Insert(tasks []Task) {
+	for _, task := range tasks {
+		h.InsertSingle(task)
+	}
+}
+
+// InsertSingle inserts single record to the queue
+func (h *Pairing) InsertSingle(task Task) {
+	node := h.free
+	if node != nil {
+		h.free = node.sibling
+		node.sibling = nil
+	} else {
+		node = &pairingNode{}
+	}
+	node.task = task
+
+	h.root = meld(h.root, node)
+	h.len++
+}
+
+// IsEmpty checks if there is no records in the heap.
+func (h *Pairing) IsEmpty() bool {
+	return (h.root == nil) || h.cut(h.root.task)
+}
+
+// PopFirst gets the task with the lowest estimate and removes it from the heap
+func (h *Pairing) PopFirst() (Task, error) {
+	if h.IsEmpty() {
+		return Task{}, fmt.Errorf("Queue is empty")
+	}
+
+	root := h.root
+	task := root.task
+	h.root = h.mergePairs(root.child)
+	h.len--
+
+	// Releasing the node, path is dropped to let it be collected
+	root.task = Task{}
+	root.child = nil
+	root.sibling = h.free
+	h.free = root
+
+	return task, nil
+}
+
+// PeekFirst gets the task with the lowest estimate without removing it
+func (h *Pairing) PeekFirst() (Task, error) {
+	if h.IsEmpty() {
+		return Task{}, fmt.Errorf("Queue is empty")
+	}
+
+	return h.root.task, nil
+}
+
+// TrimmedLen counts records which are cut off by TrimTail, but are still
+// physically stored in the heap.
+func (h *Pairing) TrimmedLen() int {
+	return h.trimmedLen(h.all())
+}
+
+// Tasks returns records which are not cut off by TrimTail in no particular
+// order. Returned slice is a copy, paths are shared with the queue.
+func (h *Pairing) Tasks() []Task {
+	return h.uncut(h.all())
+}
+
+// String implements the Stringer interface
+// Used mainly for testing
+func (h *Pairing) String() string {
+	return format("Pairing", h.all())
+}
+
+// all returns all tasks of the heap
+func (h *Pairing) all() []Task {
+	result := make([]Task, 0, h.len)
+	if h.root == nil {
+		return result
+	}
+
+	stack := []*pairingNode{h.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		result = append(result, node.task)
+
+		for child := node.child; child != nil; child = child.sibling {
+			stack = append(stack, child)
+		}
+	}
+
+	return result
+}
+
+// mergePairs merges the list of children in two passes: pairs from left
+// to right first, and then the results from right to left
+func (h *Pairing) mergePairs(first *pairingNode) *pairingNode {
+	h.pairs = h.pairs[:0]
+	for first != nil {
+		a := first
+		b := a.sibling
+		if b == nil {
+			a.sibling = nil
+			h.pairs = append(h.pairs, a)
+			break
+		}
+		first = b.sibling
+		a.sibling = nil
+		b.sibling = nil
+		h.pairs = append(h.pairs, meld(a, b))
+	}
+
+	var root *pairingNode
+	for i := len(h.pairs) - 1; i >= 0; i-- {
+		root = meld(root, h.pairs[i])
+		h.pairs[i] = nil
+	}
+
+	return root
+}
+
+// meld merges two heaps, making the one with the larger root a child
+// of the other
+func meld(a, b *pairingNode) *pairingNode {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	if before(&b.task, &a.task) {
+		a, b = b, a
+	}
+	b.sibling = a.child
+	a.child = b
+
+	return a
+}
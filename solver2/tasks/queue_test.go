@@ -0,0 +1,129 @@
+package tasks
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueues(t *testing.T) {
+	for _, qt := range QueueTypes {
+		t.Run(qt.String(), func(t *testing.T) {
+			q := CreateQueue(qt)
+			assert.True(t, q.IsEmpty())
+			_, err := q.PopFirst()
+			assert.Error(t, err)
+			_, err = q.PeekFirst()
+			assert.Error(t, err)
+
+			r := rand.New(rand.NewSource(1))
+			var estimates []types.Distance
+			for i := 0; i < 200; i++ {
+				batch := make([]Task, r.Intn(5))
+				for j := range batch {
+					batch[j].Estimate = types.Distance(r.Intn(50))
+					estimates = append(estimates, batch[j].Estimate)
+				}
+				q.Insert(batch)
+
+				// Popping some tasks in between to mix the structure
+				if r.Intn(3) == 0 {
+					first, err := q.PeekFirst()
+					assert.NoError(t, err)
+					task, err := q.PopFirst()
+					assert.NoError(t, err)
+					assert.Equal(t, first, task)
+
+					sort.Slice(estimates, func(i, j int) bool { return estimates[i] < estimates[j] })
+					assert.Equal(t, estimates[0], task.Estimate)
+					estimates = estimates[1:]
+				}
+			}
+			assert.Equal(t, len(estimates), q.Len())
+			assert.Len(t, q.Tasks(), len(estimates))
+
+			q.TrimTail(25)
+			trimmed := 0
+			for _, estimate := range estimates {
+				if estimate >= 25 {
+					trimmed++
+				}
+			}
+			assert.Equal(t, trimmed, q.TrimmedLen())
+			assert.Len(t, q.Tasks(), len(estimates)-trimmed)
+
+			sort.Slice(estimates, func(i, j int) bool { return estimates[i] < estimates[j] })
+			for _, estimate := range estimates[:len(estimates)-trimmed] {
+				task, err := q.PopFirst()
+				assert.NoError(t, err)
+				assert.Equal(t, estimate, task.Estimate)
+			}
+			assert.True(t, q.IsEmpty())
+			_, err = q.PopFirst()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestQueues_String(t *testing.T) {
+	for _, qt := range QueueTypes {
+		q := CreateQueue(qt)
+		q.Insert([]Task{{Distance: 7, Estimate: 7}, {Distance: 1, Estimate: 1}, {Distance: 5, Estimate: 5}})
+		assert.Contains(t, q.String(), ": 1 5 7", qt.String())
+		assert.NoError(t, qt.Validate())
+	}
+
+	assert.Error(t, QueueType(42).Validate())
+	assert.Equal(t, "QueueType(42)", QueueType(42).String())
+}
+
+func TestDepthQueue(t *testing.T) {
+	q := NewDepthQueue()
+	q.Insert([]Task{
+		{Distance: 1, Estimate: 10, Depth: 2},
+		{Distance: 2, Estimate: 10, Depth: 4},
+		{Distance: 3, Estimate: 5, Depth: 1},
+		{Distance: 4, Estimate: 10, Depth: 3},
+	})
+
+	var order []types.Distance
+	for task, err := q.PopFirst(); err == nil; task, err = q.PopFirst() {
+		order = append(order, task.Distance)
+	}
+	assert.Equal(t, []types.Distance{3, 2, 4, 1}, order)
+}
+
+func BenchmarkQueues(b *testing.B) {
+	// Search-like workload: the best task is replaced with several
+	// children with slightly bigger estimates
+	for _, qt := range QueueTypes {
+		for _, size := range []int{1_000, 100_000} {
+			b.Run(fmt.Sprintf("%v-%d", qt, size), func(b *testing.B) {
+				r := rand.New(rand.NewSource(1))
+				q := CreateQueue(qt)
+				for i := 0; i < size; i++ {
+					q.InsertSingle(Task{Estimate: types.Distance(r.Intn(size)), Depth: types.Index(r.Intn(16))})
+				}
+				children := make([]Task, 4)
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for n := 0; n < b.N; n++ {
+					task, _ := q.PopFirst()
+					for i := range children {
+						children[i].Estimate = task.Estimate + types.Distance(r.Intn(size/10))
+						children[i].Depth = task.Depth + 1
+					}
+					q.Insert(children)
+					for i := 1; i < len(children); i++ {
+						q.PopFirst()
+					}
+				}
+			})
+		}
+	}
+}
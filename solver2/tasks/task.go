@@ -6,11 +6,30 @@ import "github.com/Spi1y/tsp-solver/solver2/types"
 // It includes all data necessary for the calculation of a step
 // and generation of next steps
 type Task struct {
-	// An ordered list of the nodes, representing already traveled path
+	// An ordered list of the nodes, representing already traveled path.
+	// It is set only for tasks passed in or out of the solver, inside the
+	// solver paths are stored in the Tree and referenced by Node
 	Path []types.Index
+	// Path of the task in the solver Tree
+	Node NodeID
+	// Number of nodes in the path
+	Depth types.Index
 	// Distance traveled while traversing the CurrentPath
 	Distance types.Distance
 	// Estimate of lowest possible distance on that path
 	// Used for prioritization of tasks
 	Estimate types.Distance
+	// Secondary priority of tasks with equal estimates, lower rank is
+	// popped first. It is set by the branching strategy of the solver.
+	Rank int64
+}
+
+// before is the order of tasks in all queues: lower estimates go first,
+// then lower ranks
+func before(a, b *Task) bool {
+	if a.Estimate != b.Estimate {
+		return a.Estimate < b.Estimate
+	}
+
+	return a.Rank < b.Rank
 }
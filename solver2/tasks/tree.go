@@ -0,0 +1,157 @@
+package tasks
+
+import (
+	"errors"
+	"math"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// NodeID identifies a path stored in the Tree
+type NodeID int32
+
+// NoNode is a parent of the first node of a path
+const NoNode NodeID = -1
+
+// ErrTreeFull is returned when the tree has no free IDs for new nodes
+var ErrTreeFull = errors.New("Task tree has too many open paths")
+
+// treeNode is the last node of a path, the rest of the path is stored
+// in the parent node
+type treeNode struct {
+	parent NodeID
+	depth  int32
+	// References of the node: child nodes and the task owning the path
+	refs  int32
+	index types.Index
+}
+
+// Tree is a persistent prefix tree of task paths. A child task shares
+// the whole path with its parent and adds only one node, so paths are
+// stored as links to the parent in one arena. Nodes are reference counted:
+// once the path is released and has no children, its node is reused.
+type Tree struct {
+	nodes []treeNode
+	free  []NodeID
+	// Maximum number of nodes, zero means math.MaxInt32
+	limit int
+}
+
+// Reset removes all paths from the tree, keeping the allocated arena
+func (t *Tree) Reset() {
+	t.nodes = t.nodes[:0]
+	t.free = t.free[:0]
+}
+
+// Len returns the number of nodes of live paths stored in the tree
+func (t *Tree) Len() int {
+	return len(t.nodes) - len(t.free)
+}
+
+// Add adds a path, which continues the parent path with the given node.
+// Use NoNode as a parent to start a new path. The path is owned by
+// the caller until Release.
+func (t *Tree) Add(parent NodeID, index types.Index) (NodeID, error) {
+	var depth int32 = 1
+	if parent != NoNode {
+		depth = t.nodes[parent].depth + 1
+	}
+	node := treeNode{
+		parent: parent,
+		depth:  depth,
+		refs:   1,
+		index:  index,
+	}
+
+	var id NodeID
+	if last := len(t.free) - 1; last >= 0 {
+		id = t.free[last]
+		t.free = t.free[:last]
+		t.nodes[id] = node
+	} else {
+		limit := t.limit
+		if limit == 0 {
+			limit = math.MaxInt32
+		}
+		if len(t.nodes) >= limit {
+			return NoNode, ErrTreeFull
+		}
+
+		id = NodeID(len(t.nodes))
+		t.nodes = append(t.nodes, node)
+	}
+
+	if parent != NoNode {
+		t.nodes[parent].refs++
+	}
+
+	return id, nil
+}
+
+// AddPath adds a full path to the tree. Only the last node is owned by
+// the caller, the rest are kept by their children.
+func (t *Tree) AddPath(path []types.Index) (NodeID, error) {
+	id := NoNode
+	for _, index := range path {
+		next, err := t.Add(id, index)
+		if err != nil {
+			t.Release(id)
+			return NoNode, err
+		}
+
+		t.Release(id)
+		id = next
+	}
+
+	return id, nil
+}
+
+// Release drops the ownership of the path. Nodes without references are
+// freed up to the first shared parent.
+func (t *Tree) Release(id NodeID) {
+	for id != NoNode {
+		node := &t.nodes[id]
+		node.refs--
+		if node.refs > 0 {
+			return
+		}
+
+		t.free = append(t.free, id)
+		id = node.parent
+	}
+}
+
+// PathLen returns the length of the path, which is zero for NoNode
+func (t *Tree) PathLen(id NodeID) int {
+	if id == NoNode {
+		return 0
+	}
+
+	return int(t.nodes[id].depth)
+}
+
+// Last returns the last node of the path
+func (t *Tree) Last(id NodeID) types.Index {
+	return t.nodes[id].index
+}
+
+// Path appends the path to buf and returns the extended slice.
+// Buffer is reallocated only if it lacks capacity.
+func (t *Tree) Path(id NodeID, buf []types.Index) []types.Index {
+	start := len(buf)
+	end := start + t.PathLen(id)
+	if cap(buf) < end {
+		grown := make([]types.Index, start, end)
+		copy(grown, buf)
+		buf = grown
+	}
+	buf = buf[:end]
+
+	for i := end - 1; i >= start; i-- {
+		node := t.nodes[id]
+		buf[i] = node.index
+		id = node.parent
+	}
+
+	return buf
+}
@@ -0,0 +1,101 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTree(t *testing.T) {
+	tree := &Tree{}
+
+	root := addPath(t, tree, []types.Index{0})
+	a := add(t, tree, root, 2)
+	b := add(t, tree, root, 3)
+	c := add(t, tree, a, 1)
+
+	assert.Equal(t, 4, tree.Len())
+	assert.Equal(t, []types.Index{0}, tree.Path(root, nil))
+	assert.Equal(t, []types.Index{0, 2}, tree.Path(a, nil))
+	assert.Equal(t, []types.Index{0, 3}, tree.Path(b, nil))
+	assert.Equal(t, []types.Index{0, 2, 1}, tree.Path(c, nil))
+	assert.Equal(t, 3, tree.PathLen(c))
+	assert.Equal(t, types.Index(1), tree.Last(c))
+
+	// Path is appended to the buffer
+	buf := make([]types.Index, 1, 8)
+	buf[0] = 9
+	assert.Equal(t, []types.Index{9, 0, 3}, tree.Path(b, buf))
+
+	tree.Reset()
+	assert.Equal(t, 0, tree.Len())
+	d := addPath(t, tree, []types.Index{0, 4})
+	assert.Equal(t, []types.Index{0, 4}, tree.Path(d, nil))
+}
+
+func TestTree_Release(t *testing.T) {
+	tree := &Tree{}
+
+	root := addPath(t, tree, []types.Index{0, 1})
+	a := add(t, tree, root, 2)
+	b := add(t, tree, root, 3)
+	c := add(t, tree, a, 4)
+	assert.Equal(t, 5, tree.Len())
+
+	// Expanded paths are kept by their children
+	tree.Release(root)
+	tree.Release(a)
+	assert.Equal(t, 5, tree.Len())
+	assert.Equal(t, []types.Index{0, 1, 2, 4}, tree.Path(c, nil))
+
+	// Released leaf frees its node and parents without other children
+	tree.Release(c)
+	assert.Equal(t, 3, tree.Len())
+	assert.Equal(t, []types.Index{0, 1, 3}, tree.Path(b, nil))
+
+	// Freed nodes are reused
+	d := add(t, tree, b, 5)
+	e := add(t, tree, b, 6)
+	assert.Equal(t, 5, tree.Len())
+	assert.Less(t, int(d), 5)
+	assert.Less(t, int(e), 5)
+	assert.Equal(t, []types.Index{0, 1, 3, 6}, tree.Path(e, nil))
+
+	tree.Release(b)
+	tree.Release(d)
+	tree.Release(e)
+	assert.Equal(t, 0, tree.Len())
+}
+
+func TestTree_Full(t *testing.T) {
+	tree := &Tree{limit: 3}
+
+	root := addPath(t, tree, []types.Index{0, 1})
+	a := add(t, tree, root, 2)
+	_, err := tree.Add(root, 3)
+	assert.Equal(t, ErrTreeFull, err)
+
+	// Partially added path is released
+	_, err = tree.AddPath([]types.Index{0, 5})
+	assert.Equal(t, ErrTreeFull, err)
+	assert.Equal(t, 3, tree.Len())
+
+	tree.Release(a)
+	b := add(t, tree, root, 3)
+	assert.Equal(t, []types.Index{0, 1, 3}, tree.Path(b, nil))
+}
+
+func add(t *testing.T, tree *Tree, parent NodeID, index types.Index) NodeID {
+	id, err := tree.Add(parent, index)
+	assert.NoError(t, err)
+
+	return id
+}
+
+func addPath(t *testing.T, tree *Tree, path []types.Index) NodeID {
+	id, err := tree.AddPath(path)
+	assert.NoError(t, err)
+
+	return id
+}
@@ -1,13 +1,20 @@
 package solver3
 
 import (
+	"context"
 	"runtime"
+	"sync"
+	"time"
 
 	"github.com/Spi1y/tsp-solver/solver2/iterator"
+	"github.com/Spi1y/tsp-solver/solver2/tail"
 	"github.com/Spi1y/tsp-solver/solver2/tasks"
 	"github.com/Spi1y/tsp-solver/solver2/types"
 )
 
+// Number of tasks processed at once when ReproducibleOrder is set
+const batchSize = 16
+
 type solution struct {
 	path     []types.Index
 	distance types.Distance
@@ -20,29 +27,47 @@ type processingPacket struct {
 		path     []types.Index
 		distance types.Distance
 	}
+	// Solution was calculated by the tail solver
+	tail bool
+	// Number of new tasks dropped by the worker, as they can not
+	// improve the best solution
+	pruned int
+	// Task processing error
+	err error
 }
 
-func (s *Solver) taskProcessor(in <-chan *processingPacket, out chan<- *processingPacket, done <-chan struct{}) {
+func (s *Solver) taskProcessor(id int, in <-chan *processingPacket, out chan<- *processingPacket, done <-chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	if s.Logger != nil {
+		s.Logger.Debug("worker started", "worker", id)
+	}
+
 	size := len(s.matrix)
 	iter := &iterator.Iterator{}
 	iter.Init(types.Index(size))
 	buff := make([]types.Distance, size)
+	ts := &tail.Solver{}
 
+	var busy time.Duration
 	for {
 		select {
 		case pkt := <-in:
-			err := s.processTask(iter, buff, pkt)
-			if err != nil {
-				panic(err)
-			}
+			start := time.Now()
+			pkt.err = s.processTask(iter, ts, buff, pkt)
+			busy += time.Since(start)
 			out <- pkt
 		case <-done:
+			// Each worker owns its own slot, so no locking is required
+			s.stats.WorkerBusy[id] = busy
+			if s.Logger != nil {
+				s.Logger.Debug("worker stopped", "worker", id, "busy", busy)
+			}
 			return
 		}
 	}
 }
 
-func (s *Solver) processTask(it *iterator.Iterator, buf []types.Distance, pkt *processingPacket) error {
+func (s *Solver) processTask(it *iterator.Iterator, ts *tail.Solver, buf []types.Distance, pkt *processingPacket) error {
 	// TODO - try aggressive approach with full path first
 
 	t := pkt.task
@@ -55,16 +80,19 @@ func (s *Solver) processTask(it *iterator.Iterator, buf []types.Distance, pkt *p
 
 	currNode := t.Path[len(t.Path)-1]
 	nodesLeft := len(nextNodes)
+	pkt.tail = false
+	pkt.pruned = 0
 
-	if nodesLeft <= int(s.RecursiveThreshold) {
-		// Calculate remaining path through brute-force recursion
-		tailpath, taildistance := s.solveRecursively(currNode, nextNodes)
+	if nodesLeft <= int(s.threshold) {
+		// Calculate remaining path through dynamic programming
+		tailpath, taildistance := s.solveRecursively(ts, currNode, nextNodes)
 		path := make([]types.Index, len(t.Path), len(t.Path)+len(tailpath))
 		copy(path, t.Path)
 
 		pkt.solution.path = append(path, tailpath...)
 		pkt.solution.distance = t.Distance + taildistance
 		pkt.newTasks = pkt.newTasks[:0]
+		pkt.tail = true
 
 		return nil
 	}
@@ -87,7 +115,12 @@ func (s *Solver) processTask(it *iterator.Iterator, buf []types.Distance, pkt *p
 	pkt.newTasks = pkt.newTasks[:nodesLeft]
 	pkt.solution.path = pkt.solution.path[:0]
 
-	for i, nextNode := range nextNodes {
+	// Children which can not improve the best solution are dropped right
+	// away instead of being sent back
+	bound := s.bound.Load()
+	count := 0
+
+	for _, nextNode := range nextNodes {
 
 		var estimate types.Distance
 		cols, err := it.ColsToIterate(nextNode)
@@ -146,16 +179,23 @@ func (s *Solver) processTask(it *iterator.Iterator, buf []types.Distance, pkt *p
 			estimate += buf[colIndex]
 		}
 
-		path := pathsSlice[i*newPathLen : (i+1)*newPathLen]
+		distance := t.Distance + s.matrix[currNode][nextNode]
+		if distance+estimate >= bound {
+			pkt.pruned++
+			continue
+		}
+
+		path := pathsSlice[count*newPathLen : (count+1)*newPathLen]
 		copy(path, t.Path)
 		path[newPathLen-1] = nextNode
 
-		distance := t.Distance + s.matrix[currNode][nextNode]
-
-		pkt.newTasks[i].Path = path
-		pkt.newTasks[i].Distance = distance
-		pkt.newTasks[i].Estimate = distance + estimate
+		pkt.newTasks[count].Path = path
+		pkt.newTasks[count].Depth = types.Index(newPathLen)
+		pkt.newTasks[count].Distance = distance
+		pkt.newTasks[count].Estimate = distance + estimate
+		count++
 	}
+	pkt.newTasks = pkt.newTasks[:count]
 
 	return nil
 }
@@ -168,87 +208,123 @@ func (s *Solver) newPacket() *processingPacket {
 	return &pkt
 }
 
-func (s *Solver) solveParallel() {
+func (s *Solver) solveParallel(ctx context.Context) error {
 	if s.taskQueue.IsEmpty() {
-		return
+		return nil
 	}
 
-	threadscount := runtime.NumCPU() - 1
-	if threadscount == 0 {
-		threadscount = 1
+	threadscount := s.workers()
+	s.stats.WorkerBusy = make([]time.Duration, threadscount)
+
+	if !s.ReproducibleOrder {
+		return s.solveStealing(ctx, threadscount)
 	}
 
+	// Reproducible order requires a central dispatcher
 	toProcessors := make(chan *processingPacket, threadscount)
 	fromProcessors := make(chan *processingPacket, threadscount)
 	stopProcessing := make(chan struct{}, threadscount)
 
+	wg := &sync.WaitGroup{}
+	wg.Add(threadscount)
 	for i := 0; i < threadscount; i++ {
-		go s.taskProcessor(toProcessors, fromProcessors, stopProcessing)
+		go s.taskProcessor(i, toProcessors, fromProcessors, stopProcessing, wg)
 	}
 
-	var pkt *processingPacket
-	busyThreads := 0
+	err := s.processBatches(ctx, toProcessors, fromProcessors)
 
-	// Sending initial tasks
 	for i := 0; i < threadscount; i++ {
-		if s.taskQueue.IsEmpty() {
-			break
-		}
-		// And we have new work for them
-		task, err := s.taskQueue.PopFirst()
-		if err != nil {
-			panic(err)
-		}
+		stopProcessing <- struct{}{}
+	}
+	wg.Wait()
+
+	return err
+}
 
-		pkt := s.newPacket()
-		pkt.task = task
-		toProcessors <- pkt
-		busyThreads++
+// workers returns the number of worker goroutines to start
+func (s *Solver) workers() int {
+	if s.Workers > 0 {
+		return s.Workers
 	}
 
-	for {
-		select {
-		case pkt = <-fromProcessors:
-			if len(pkt.newTasks) != 0 {
-				s.taskQueue.Insert(pkt.newTasks)
-			}
-			if len(pkt.solution.path) != 0 {
-				s.newSolutionFound(pkt.solution.path, pkt.solution.distance)
-			}
+	threadscount := runtime.NumCPU() - 1
+	if threadscount == 0 {
+		threadscount = 1
+	}
 
-			if !s.taskQueue.IsEmpty() {
-				task, err := s.taskQueue.PopFirst()
-				if err != nil {
-					panic(err)
-				}
+	return threadscount
+}
 
-				pkt.task = task
-				toProcessors <- pkt
-			} else {
-				busyThreads--
-			}
+// processBatches sends tasks to workers in batches of a fixed size and
+// processes results in the order of sending. That way the expansion order does
+// not depend on the workers scheduling and their count.
+func (s *Solver) processBatches(ctx context.Context, toProcessors chan<- *processingPacket, fromProcessors <-chan *processingPacket) error {
+	batch := make([]*processingPacket, batchSize)
+	for i := range batch {
+		batch[i] = s.newPacket()
+	}
+
+	for !s.taskQueue.IsEmpty() {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 
-		for busyThreads != threadscount && !s.taskQueue.IsEmpty() {
-			// There are processors waiting for work
-			// and we have new work for them
+		count := 0
+		for count < batchSize && !s.taskQueue.IsEmpty() {
 			task, err := s.taskQueue.PopFirst()
 			if err != nil {
 				panic(err)
 			}
 
-			pkt = s.newPacket()
-			pkt.task = task
-			toProcessors <- pkt
-			busyThreads++
+			batch[count].task = task
+			count++
 		}
 
-		if s.taskQueue.IsEmpty() && busyThreads == 0 {
-			break
+		// Channels are smaller than the batch, so we have to receive
+		// while sending to avoid blocking workers
+		sent, received := 0, 0
+		for received < count {
+			if sent < count {
+				select {
+				case toProcessors <- batch[sent]:
+					sent++
+				case <-fromProcessors:
+					received++
+				}
+				continue
+			}
+
+			<-fromProcessors
+			received++
+		}
+
+		// Errors are checked first to keep the solver state consistent
+		for _, pkt := range batch[:count] {
+			if pkt.err != nil {
+				return pkt.err
+			}
+		}
+		for _, pkt := range batch[:count] {
+			s.processResult(pkt)
 		}
 	}
 
-	for i := 0; i < threadscount; i++ {
-		stopProcessing <- struct{}{}
+	return nil
+}
+
+// processResult updates solver state with the results of the processed task
+func (s *Solver) processResult(pkt *processingPacket) {
+	s.stats.TasksExpanded++
+	s.stats.TasksCreated += pkt.pruned
+	s.stats.PrunedByBound += pkt.pruned
+	if pkt.tail {
+		s.stats.TailCalls++
+	}
+	if len(pkt.newTasks) != 0 {
+		s.insertTasks(pkt.newTasks)
+	}
+	s.logProgress()
+	if len(pkt.solution.path) != 0 {
+		s.newSolutionFound(pkt.solution.path, pkt.solution.distance)
 	}
 }
@@ -0,0 +1,94 @@
+package solver3
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/Spi1y/tsp-solver/solver2/iterator"
+	"github.com/Spi1y/tsp-solver/solver2/tail"
+	"github.com/Spi1y/tsp-solver/solver2/tasks"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolver_SolveParallelError(t *testing.T) {
+	tt := solveTestCase7Points()
+
+	for _, reproducible := range []bool{false, true} {
+		before := runtime.NumGoroutine()
+
+		s := &Solver{Workers: 4, ReproducibleOrder: reproducible}
+		s.matrix = tt.distanceMatrix
+		s.taskQueue = tasks.NewHeapQueue()
+		s.bound.Store(math.MaxUint32)
+		// Good tasks keep other workers busy while the broken one fails
+		s.taskQueue.Insert([]tasks.Task{
+			{Path: []types.Index{0}},
+			{Path: []types.Index{0, 1}},
+			{Path: []types.Index{0, 2}},
+			{Path: []types.Index{3, 0}},
+		})
+
+		err := s.solveParallel(context.Background())
+		assert.EqualError(t, err, "Path must include 0 node as the first element")
+		assertNoLeaks(t, before)
+	}
+}
+
+func TestSolver_SolveParallelCanceledNoLeaks(t *testing.T) {
+	tt := solveTestCase7Points()
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := &Solver{Workers: 8}
+	_, _, _, err := s.SolveContext(ctx, tt.distanceMatrix)
+
+	assert.Error(t, err)
+	assertNoLeaks(t, before)
+}
+
+// assertNoLeaks waits for the goroutines count to get back to the given value
+func assertNoLeaks(t *testing.T, before int) {
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before, "goroutines leaked")
+}
+
+func TestSolver_ProcessTaskPruning(t *testing.T) {
+	tt := solveTestCase7Points()
+	size := len(tt.distanceMatrix)
+
+	tests := []struct {
+		name       string
+		bound      types.Distance
+		wantTasks  int
+		wantPruned int
+	}{
+		{"no incumbent", math.MaxUint32, size - 1, 0},
+		{"everything pruned", 1, 0, size - 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &Solver{matrix: tt.distanceMatrix}
+			s.bound.Store(tc.bound)
+
+			it := &iterator.Iterator{}
+			it.Init(types.Index(size))
+			pkt := s.newPacket()
+			pkt.task = tasks.Task{Path: []types.Index{0}}
+
+			err := s.processTask(it, &tail.Solver{}, make([]types.Distance, size), pkt)
+			assert.NoError(t, err)
+			assert.Len(t, pkt.newTasks, tc.wantTasks)
+			assert.Equal(t, tc.wantPruned, pkt.pruned)
+		})
+	}
+}
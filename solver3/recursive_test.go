@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/Spi1y/tsp-solver/solver2/iterator"
+	"github.com/Spi1y/tsp-solver/solver2/tail"
 	"github.com/Spi1y/tsp-solver/solver2/tasks"
 	"github.com/Spi1y/tsp-solver/solver2/types"
 	"github.com/stretchr/testify/assert"
@@ -25,7 +26,7 @@ func TestSolver_SolveRecursively(t *testing.T) {
 			it.SetPath([]types.Index{0})
 			nextNodes := it.NodesToVisit()
 
-			path, dist := s.solveRecursively(0, nextNodes)
+			path, dist := s.solveRecursively(&tail.Solver{}, 0, nextNodes)
 			fullpath := make([]types.Index, 0)
 			fullpath = append(fullpath, 0)
 			fullpath = append(fullpath, path...)
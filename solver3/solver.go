@@ -1,65 +1,272 @@
 package solver3
 
 import (
+	"context"
 	"errors"
+	"log/slog"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/metrics"
+	"github.com/Spi1y/tsp-solver/solver2/checkpoint"
+	"github.com/Spi1y/tsp-solver/solver2/searchlog"
+	"github.com/Spi1y/tsp-solver/solver2/stats"
+	"github.com/Spi1y/tsp-solver/solver2/tail"
 	"github.com/Spi1y/tsp-solver/solver2/tasks"
 	"github.com/Spi1y/tsp-solver/solver2/types"
 )
 
+// DefaultLogEvery is the default number of expanded tasks between progress log records
+const DefaultLogEvery = searchlog.DefaultEvery
+
 // Solver is a TSP solver object. It is used to set a distance matrix and start
 // calculations
 type Solver struct {
+	// Tails with that many nodes left are solved with dynamic programming
+	// instead of branching. Use tail.ThresholdAuto to select it automatically
 	RecursiveThreshold types.Index
+	// Type of the tasks queue. Zero value means tasks.QueueHeap
+	Queue tasks.QueueType
+	// Number of worker goroutines. Zero value means runtime.NumCPU() - 1
+	Workers int
+	// Optional metrics collection, solves are not reported if it is nil
+	Metrics *metrics.Metrics
+	// Optional logger for debug events of the search
+	Logger *slog.Logger
+	// Search progress is logged every LogEvery expanded tasks.
+	// Zero value means DefaultLogEvery
+	LogEvery int
+	// Deterministic makes the result independent of the workers scheduling.
+	// Ties on equal distance are broken by the lexicographic path order, so
+	// tasks with the estimate equal to the best distance are explored too.
+	Deterministic bool
+	// ReproducibleOrder makes the expansion order (and thus the result and
+	// statistics) reproducible by processing tasks in fixed size batches
+	ReproducibleOrder bool
 
 	// Distance matrix
 	matrix [][]types.Distance
 	// Tasks queue
-	taskQueue *tasks.Queue
+	taskQueue tasks.Queue
+	// Resolved recursive threshold
+	threshold types.Index
 
 	// Current best solution
 	bestSolution         []types.Index
 	bestSolutionDistance types.Distance
+
+	// Shared state of the work-stealing search. The mutex guards the best
+	// solution, while its distance is published through the atomic bound
+	mu       sync.Mutex
+	bound    atomic.Uint32
+	pending  atomic.Int64
+	expanded atomic.Int64
+
+	// Search telemetry
+	stats    stats.Stats
+	start    time.Time
+	logEvery int
 }
 
 // Solve solves the TSP problem with a given distance matrix.
-func (s *Solver) Solve(m [][]types.Distance) ([]types.Index, types.Distance, error) {
+// Along with the solution it returns the search statistics.
+func (s *Solver) Solve(m [][]types.Distance) ([]types.Index, types.Distance, stats.Stats, error) {
+	return s.SolveContext(context.Background(), m)
+}
+
+// SolveContext is the same as Solve, but the search can be interrupted with
+// the context. In that case the best solution found so far is returned along
+// with the context error. If any worker fails, other workers are stopped and
+// the first error is returned.
+func (s *Solver) SolveContext(ctx context.Context, m [][]types.Distance) ([]types.Index, types.Distance, stats.Stats, error) {
+	err := s.init(m)
+	if err != nil {
+		return nil, 0, stats.Stats{}, err
+	}
+
+	rootTask := tasks.Task{
+		Path:     []types.Index{0},
+		Depth:    1,
+		Distance: 0,
+		Estimate: 0,
+	}
+	s.insertTasks([]tasks.Task{rootTask})
+
+	err = s.solveParallel(ctx)
+	s.finish(err)
+
+	return s.bestSolution, s.bestSolutionDistance, s.stats, err
+}
+
+// Checkpoint makes a snapshot of the last search state. Usually it is called
+// after SolveContext was interrupted, so the search can be continued later
+// with Resume.
+func (s *Solver) Checkpoint() (*checkpoint.Checkpoint, error) {
+	return checkpoint.Snapshot(s.matrix, s.taskQueue, s.bestSolution, s.bestSolutionDistance, s.stats, nil)
+}
+
+// Resume continues the search from the checkpoint. The matrix must be the same
+// as the one the checkpoint was made for. Statistics are accumulated over
+// all runs of the search.
+func (s *Solver) Resume(ctx context.Context, c *checkpoint.Checkpoint, m [][]types.Distance) ([]types.Index, types.Distance, stats.Stats, error) {
+	err := c.Check(m)
+	if err != nil {
+		return nil, 0, stats.Stats{}, err
+	}
+
+	err = s.init(m)
+	if err != nil {
+		return nil, 0, stats.Stats{}, err
+	}
+
+	s.stats, s.start, err = c.Restore(s.taskQueue, nil)
+	if err != nil {
+		return nil, 0, stats.Stats{}, err
+	}
+	if c.Distance != 0 {
+		s.bestSolution = c.Path
+		s.bestSolutionDistance = c.Distance
+		s.taskQueue.TrimTail(s.pruneBound())
+		s.bound.Store(s.pruneBound())
+	}
+
+	err = s.solveParallel(ctx)
+	s.finish(err)
+
+	return s.bestSolution, s.bestSolutionDistance, s.stats, err
+}
+
+// init validates the distance matrix and resets the solver state
+func (s *Solver) init(m [][]types.Distance) error {
 	size := len(m)
 
 	if size == 0 {
-		return nil, 0, errors.New("Distance matrix is empty")
+		return errors.New("Distance matrix is empty")
 	}
 
-	for i := range m {
-		if len(m[i]) != size {
-			return nil, 0, errors.New("Distance matrix is not square")
-		}
+	err := s.Queue.Validate()
+	if err != nil {
+		return err
+	}
+
+	// Matrix is copied to one backing array for better memory locality
+	fm, err := matrix.FromRows(m, math.MaxUint32)
+	if err != nil {
+		return err
 	}
 
-	s.matrix = m
+	s.start = time.Now()
+	s.stats = stats.Stats{}
+	s.logEvery = searchlog.Every(s.LogEvery)
+	s.matrix = fm.Rows()
 	s.bestSolution = []types.Index{}
 	s.bestSolutionDistance = 0
-	s.taskQueue = tasks.NewHeapQueue()
+	s.taskQueue = tasks.CreateQueue(s.Queue)
+	s.bound.Store(math.MaxUint32)
+	s.expanded.Store(0)
+	s.threshold = tail.Threshold(s.RecursiveThreshold, size)
 
-	rootTask := tasks.Task{
-		Path:     []types.Index{0},
-		Distance: 0,
-		Estimate: 0,
+	return nil
+}
+
+// finish fills the statistics which are calculated at the end of the search
+// and reports the solve to metrics
+func (s *Solver) finish(err error) {
+	s.stats.LowerBound = s.bestSolutionDistance
+	if task, err := s.taskQueue.PeekFirst(); err == nil {
+		// Search was interrupted, lowest estimate of remaining tasks is the bound
+		s.stats.LowerBound = task.Estimate
 	}
-	s.taskQueue.Insert([]tasks.Task{rootTask})
 
-	s.solveParallel()
+	trimmed := s.taskQueue.TrimmedLen()
+	s.stats.PrunedByTrim += trimmed
+	s.stats.Open = s.taskQueue.Len() - trimmed
+	s.stats.Duration = time.Since(s.start)
 
-	return s.bestSolution, s.bestSolutionDistance, nil
+	s.Metrics.ObserveSolve("solver3", len(s.matrix), s.bestSolutionDistance, s.stats, err)
+
+	searchlog.Finish(s.Logger, err, s.bestSolutionDistance, s.stats)
 }
 
-func (s *Solver) newSolutionFound(path []types.Index, distance types.Distance) {
-	if (s.bestSolutionDistance != 0) && (distance >= s.bestSolutionDistance) {
+// logProgress periodically logs the state of the search
+func (s *Solver) logProgress() {
+	if s.Logger == nil || s.stats.TasksExpanded%s.logEvery != 0 {
 		return
 	}
 
+	lowerBound := s.bestSolutionDistance
+	if task, err := s.taskQueue.PeekFirst(); err == nil {
+		lowerBound = task.Estimate
+	}
+	searchlog.Progress(s.Logger, s.stats.TasksExpanded, s.taskQueue.Len(), s.bestSolutionDistance, s.start, "lowerBound", lowerBound)
+}
+
+// insertTasks inserts new tasks into the queue, skipping ones that can not
+// improve the current best solution
+func (s *Solver) insertTasks(newTasks []tasks.Task) {
+	s.stats.TasksCreated += len(newTasks)
+
+	if len(s.bestSolution) != 0 {
+		bound := s.pruneBound()
+		count := 0
+		for _, t := range newTasks {
+			if t.Estimate >= bound {
+				s.stats.PrunedByBound++
+				continue
+			}
+			newTasks[count] = t
+			count++
+		}
+		newTasks = newTasks[:count]
+	}
+
+	s.taskQueue.Insert(newTasks)
+	s.stats.UpdateQueueLen(s.taskQueue.Len())
+}
+
+func (s *Solver) newSolutionFound(path []types.Index, distance types.Distance) {
+	if s.bestSolutionDistance != 0 {
+		if distance > s.bestSolutionDistance {
+			return
+		}
+		if (distance == s.bestSolutionDistance) && !(s.Deterministic && pathLess(path, s.bestSolution)) {
+			return
+		}
+	}
+
 	s.bestSolution = path
 	s.bestSolutionDistance = distance
+	s.stats.Incumbents = append(s.stats.Incumbents, stats.Incumbent{
+		Distance: distance,
+		Elapsed:  time.Since(s.start),
+	})
+	searchlog.Incumbent(s.Logger, distance, path, int(s.expanded.Load()), s.start)
+
+	s.taskQueue.TrimTail(s.pruneBound())
+	s.bound.Store(s.pruneBound())
+}
+
+// pruneBound returns the estimate starting from which tasks can not improve
+// the best solution
+func (s *Solver) pruneBound() types.Distance {
+	if s.Deterministic && (s.bestSolutionDistance < math.MaxUint32) {
+		// Equal distance solutions may still win on path order
+		return s.bestSolutionDistance + 1
+	}
+
+	return s.bestSolutionDistance
+}
+
+// pathLess compares paths of equal length in lexicographic order
+func pathLess(a, b []types.Index) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
 
-	s.taskQueue.TrimTail(distance)
+	return false
 }
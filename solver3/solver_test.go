@@ -1,9 +1,19 @@
 package solver3
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"strings"
 	"testing"
 
+	"github.com/Spi1y/tsp-solver/matrix"
+	"github.com/Spi1y/tsp-solver/metrics"
+	"github.com/Spi1y/tsp-solver/solver2"
+	"github.com/Spi1y/tsp-solver/solver2/checkpoint"
+	"github.com/Spi1y/tsp-solver/solver2/tasks"
 	"github.com/Spi1y/tsp-solver/solver2/types"
 	"github.com/stretchr/testify/assert"
 )
@@ -14,7 +24,7 @@ func TestSolverSolve(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &Solver{}
 			s.RecursiveThreshold = 0
-			path, dist, err := s.Solve(tt.distanceMatrix)
+			path, dist, _, err := s.Solve(tt.distanceMatrix)
 
 			// With concurrency we can not predict which of equal length paths
 			// will be processed first and selected as a winner. So for cases where
@@ -42,7 +52,7 @@ func TestSolverSolveRecursiveTail(t *testing.T) {
 				t.Run(name, func(t *testing.T) {
 					s := &Solver{}
 					s.RecursiveThreshold = types.Index(i)
-					path, dist, err := s.Solve(tt.distanceMatrix)
+					path, dist, _, err := s.Solve(tt.distanceMatrix)
 
 					// With concurrency we can not predict which of equal length paths
 					// will be processed first and selected as a winner. So for cases where
@@ -63,6 +73,248 @@ func TestSolverSolveRecursiveTail(t *testing.T) {
 	}
 }
 
+func TestSolverSolveWorkers(t *testing.T) {
+	tt := solveTestCase7Points()
+	for _, workers := range []int{1, 3, 16} {
+		t.Run(fmt.Sprintf("Workers %v", workers), func(t *testing.T) {
+			s := &Solver{Workers: workers}
+			path, dist, st, err := s.Solve(tt.distanceMatrix)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.path, path)
+			assert.Equal(t, tt.dist, dist)
+			assert.Len(t, st.WorkerBusy, workers)
+		})
+	}
+}
+
+func TestSolverSolveQueues(t *testing.T) {
+	tt := solveTestCase7Points()
+	for _, qt := range tasks.QueueTypes {
+		t.Run(qt.String(), func(t *testing.T) {
+			s := &Solver{Queue: qt, Workers: 3}
+			_, dist, _, err := s.Solve(tt.distanceMatrix)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.dist, dist)
+		})
+	}
+
+	s := &Solver{Queue: tasks.QueueType(42)}
+	_, _, _, err := s.Solve(tt.distanceMatrix)
+	assert.Error(t, err)
+}
+
+func TestSolverSolveStats(t *testing.T) {
+	tests := solverTestCases()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Solver{}
+			_, dist, st, err := s.Solve(tt.distanceMatrix)
+			assert.NoError(t, err)
+
+			// Every created task is either expanded or pruned
+			assert.Equal(t, st.TasksCreated, st.TasksExpanded+st.PrunedByBound+st.PrunedByTrim+st.Open)
+			assert.NotZero(t, st.MaxQueueLen)
+			assert.Equal(t, dist, st.LowerBound)
+			assert.Zero(t, st.TailCalls)
+			if assert.NotEmpty(t, st.Incumbents) {
+				assert.Equal(t, dist, st.Incumbents[len(st.Incumbents)-1].Distance)
+			}
+			assert.NotEmpty(t, st.WorkerBusy)
+		})
+	}
+}
+
+func TestSolverSolveCanceled(t *testing.T) {
+	tt := solveTestCase7Points()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := &Solver{Metrics: metrics.New()}
+	_, _, st, err := s.SolveContext(ctx, tt.distanceMatrix)
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.NotZero(t, st.Open)
+	assert.LessOrEqual(t, st.LowerBound, tt.dist)
+
+	var b strings.Builder
+	s.Metrics.WriteTo(&b)
+	assert.Contains(t, b.String(), `tsp_solve_cancellations_total{engine="solver3"} 1`)
+}
+
+func TestSolverSolveLogging(t *testing.T) {
+	tt := solveTestCase7Points()
+
+	var b strings.Builder
+	s := &Solver{
+		Logger:   slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		LogEvery: 1,
+	}
+	_, _, _, err := s.Solve(tt.distanceMatrix)
+	assert.NoError(t, err)
+
+	out := b.String()
+	assert.Contains(t, out, "msg=\"new incumbent\"")
+	assert.Contains(t, out, "msg=\"search progress\"")
+	assert.Contains(t, out, "msg=\"search finished\"")
+	assert.NotContains(t, out, "msg=\"search interrupted\"")
+	assert.Contains(t, out, "msg=\"worker started\"")
+	assert.Contains(t, out, "msg=\"worker stopped\"")
+}
+
+func TestSolverResume(t *testing.T) {
+	tests := solverTestCases()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Search is interrupted after a few expansions
+			s := &Solver{
+				Workers:  2,
+				Logger:   slog.New(&cancelHandler{cancel: cancel}),
+				LogEvery: 2,
+			}
+			_, _, _, err := s.SolveContext(ctx, tt.distanceMatrix)
+			c, cerr := s.Checkpoint()
+			assert.NoError(t, cerr)
+
+			resumed := &Solver{Workers: 2}
+			path, dist, st, rerr := resumed.Resume(context.Background(), c, tt.distanceMatrix)
+			assert.NoError(t, rerr)
+			assert.Len(t, path, len(tt.distanceMatrix)+1)
+			assert.Equal(t, tt.dist, dist)
+			assert.Equal(t, st.TasksCreated, st.TasksExpanded+st.PrunedByBound+st.PrunedByTrim+st.Open)
+			if (err != nil) && (len(c.Tasks) != 0) {
+				assert.Greater(t, st.TasksExpanded, c.Stats.TasksExpanded)
+			}
+		})
+	}
+}
+
+func TestSolverResumeFromSolver2(t *testing.T) {
+	tt := solveTestCase7Points()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Checkpoints are shared between engines
+	s2 := &solver2.Solver{}
+	_, _, _, err := s2.SolveContext(ctx, tt.distanceMatrix)
+	assert.Error(t, err)
+	c, err := s2.Checkpoint()
+	assert.NoError(t, err)
+
+	s := &Solver{}
+	path, dist, _, err := s.Resume(context.Background(), c, tt.distanceMatrix)
+	assert.NoError(t, err)
+	assert.Equal(t, tt.path, path)
+	assert.Equal(t, tt.dist, dist)
+
+	_, _, _, err = s.Resume(context.Background(), c, solveTestCase4Points().distanceMatrix)
+	assert.Equal(t, checkpoint.ErrMismatch, err)
+}
+
+// cancelHandler is a log handler, which cancels the search on progress records
+type cancelHandler struct {
+	cancel func()
+}
+
+func (h *cancelHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *cancelHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *cancelHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *cancelHandler) Handle(_ context.Context, r slog.Record) error {
+	if r.Message == "search progress" {
+		h.cancel()
+	}
+	return nil
+}
+
+func TestSolverSolveDeterministic(t *testing.T) {
+	tests := solverTestCases()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.path
+			if len(want) == 0 {
+				// Lexicographically smallest of several optimal solutions
+				want = []types.Index{0, 1, 4, 3, 2, 0}
+			}
+
+			for _, workers := range []int{1, 2, 4, 8} {
+				for i := 0; i < 1+len(tt.distanceMatrix); i++ {
+					s := &Solver{Deterministic: true, Workers: workers}
+					s.RecursiveThreshold = types.Index(i)
+					path, dist, _, err := s.Solve(tt.distanceMatrix)
+
+					assert.NoError(t, err)
+					assert.Equal(t, want, path)
+					assert.Equal(t, tt.dist, dist)
+				}
+			}
+		})
+	}
+}
+
+func TestSolverSolveReproducibleOrder(t *testing.T) {
+	tests := solverTestCases()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Solver{ReproducibleOrder: true, Workers: 1}
+			path1, dist1, st1, err := s.Solve(tt.distanceMatrix)
+			assert.NoError(t, err)
+			s.Workers = 5
+			path2, dist2, st2, err := s.Solve(tt.distanceMatrix)
+			assert.NoError(t, err)
+
+			assert.Equal(t, path1, path2)
+			assert.Equal(t, dist1, dist2)
+			assert.Equal(t, tt.dist, dist1)
+			assert.Equal(t, st1.TasksCreated, st2.TasksCreated)
+			assert.Equal(t, st1.TasksExpanded, st2.TasksExpanded)
+			assert.Equal(t, st1.PrunedByBound, st2.PrunedByBound)
+			assert.Equal(t, st1.MaxQueueLen, st2.MaxQueueLen)
+			assert.Equal(t, len(st1.Incumbents), len(st2.Incumbents))
+		})
+	}
+}
+
+func TestSolverSolveMatrix(t *testing.T) {
+	tt := solveTestCase4Points()
+	m, err := matrix.FromRows(tt.distanceMatrix, math.MaxUint32)
+	assert.NoError(t, err)
+
+	s := &Solver{}
+	path, dist, _, err := s.SolveMatrix(context.Background(), m)
+	assert.NoError(t, err)
+	assert.Equal(t, tt.path, path)
+	assert.Equal(t, tt.dist, dist)
+
+	// Forbidding an edge of the optimal tour leads to another one
+	m.Forbid(int(tt.path[1]), int(tt.path[2]))
+	path, dist, _, err = s.SolveMatrix(context.Background(), m)
+	assert.NoError(t, err)
+	assert.Greater(t, dist, tt.dist)
+	for i := 1; i < len(path); i++ {
+		assert.False(t, m.Forbidden(int(path[i-1]), int(path[i])))
+	}
+
+	// All edges from the root are forbidden
+	for j := 1; j < m.Size(); j++ {
+		m.Forbid(0, j)
+	}
+	_, _, _, err = s.SolveMatrix(context.Background(), m)
+	assert.Equal(t, ErrNoTour, err)
+
+	huge := matrix.New[types.Distance](3, math.MaxUint32)
+	huge.Set(0, 1, math.MaxUint32/4)
+	_, _, _, err = s.SolveMatrix(context.Background(), huge)
+	assert.Error(t, err)
+
+	_, _, _, err = s.SolveMatrix(context.Background(), matrix.New[types.Distance](0, math.MaxUint32))
+	assert.Error(t, err)
+}
+
 func solverTestCases() []*solveTestCase {
 	result := []*solveTestCase{}
 	result = append(result, solveTestCase2PointsSynth())
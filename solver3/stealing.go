@@ -0,0 +1,241 @@
+package solver3
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/Spi1y/tsp-solver/solver2/iterator"
+	"github.com/Spi1y/tsp-solver/solver2/searchlog"
+	"github.com/Spi1y/tsp-solver/solver2/stats"
+	"github.com/Spi1y/tsp-solver/solver2/tail"
+	"github.com/Spi1y/tsp-solver/solver2/tasks"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Number of idle iterations a worker yields the processor before
+// starting to sleep between steal attempts
+const spinLimit = 64
+
+// stealingWorker is a worker of the work-stealing search. It owns a local
+// tasks queue, which other workers steal from when they run out of work.
+type stealingWorker struct {
+	// Guards the queue and trimmed counter
+	mu      sync.Mutex
+	queue   tasks.Queue
+	trimmed int
+
+	// Local statistics, merged after the search
+	stats stats.Stats
+}
+
+// pop gets the best task from the worker heap. If it can not improve the best
+// solution, the whole heap is dropped, as other tasks are even worse.
+func (s *Solver) pop(w *stealingWorker) (tasks.Task, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	task, err := w.queue.PopFirst()
+	if err != nil {
+		return tasks.Task{}, false
+	}
+
+	if task.Estimate >= s.bound.Load() {
+		dropped := 1 + w.queue.Len()
+		w.trimmed += dropped
+		w.queue = tasks.CreateQueue(s.Queue)
+		s.pending.Add(int64(-dropped))
+		return tasks.Task{}, false
+	}
+
+	return task, true
+}
+
+// nextTask gets a task from the worker own heap or steals it from others
+func (s *Solver) nextTask(id int, workers []*stealingWorker) (tasks.Task, bool) {
+	if task, ok := s.pop(workers[id]); ok {
+		return task, true
+	}
+
+	for i := 1; i < len(workers); i++ {
+		if task, ok := s.pop(workers[(id+i)%len(workers)]); ok {
+			return task, true
+		}
+	}
+
+	return tasks.Task{}, false
+}
+
+// solveStealing runs the search on workers with local heaps and without
+// a central dispatcher. Idle workers steal tasks from others, and the best
+// solution distance is shared through the atomic bound.
+func (s *Solver) solveStealing(ctx context.Context, threadscount int) error {
+	workersCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := make([]*stealingWorker, threadscount)
+	for i := range workers {
+		workers[i] = &stealingWorker{queue: tasks.CreateQueue(s.Queue)}
+	}
+
+	// Seeding the first worker, others will steal from it
+	s.pending.Store(0)
+	for !s.taskQueue.IsEmpty() {
+		task, err := s.taskQueue.PopFirst()
+		if err != nil {
+			panic(err)
+		}
+		workers[0].queue.InsertSingle(task)
+		s.pending.Add(1)
+	}
+
+	var processingErr error
+	var errOnce sync.Once
+	fail := func(err error) {
+		errOnce.Do(func() {
+			processingErr = err
+			cancel()
+		})
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(threadscount)
+	for i := range workers {
+		go s.stealingProcessor(workersCtx, i, workers, fail, wg)
+	}
+	wg.Wait()
+
+	// Merging statistics and tasks left after interruption
+	for _, w := range workers {
+		for task, err := w.queue.PopFirst(); err == nil; task, err = w.queue.PopFirst() {
+			s.taskQueue.InsertSingle(task)
+		}
+
+		s.stats.TasksCreated += w.stats.TasksCreated
+		s.stats.TasksExpanded += w.stats.TasksExpanded
+		s.stats.PrunedByBound += w.stats.PrunedByBound
+		s.stats.PrunedByTrim += w.trimmed
+		s.stats.TailCalls += w.stats.TailCalls
+		s.stats.UpdateQueueLen(w.stats.MaxQueueLen)
+	}
+
+	if processingErr != nil {
+		return processingErr
+	}
+
+	return ctx.Err()
+}
+
+func (s *Solver) stealingProcessor(ctx context.Context, id int, workers []*stealingWorker, fail func(error), wg *sync.WaitGroup) {
+	defer wg.Done()
+	if s.Logger != nil {
+		s.Logger.Debug("worker started", "worker", id)
+	}
+
+	size := len(s.matrix)
+	iter := &iterator.Iterator{}
+	iter.Init(types.Index(size))
+	buff := make([]types.Distance, size)
+	ts := &tail.Solver{}
+	pkt := s.newPacket()
+	w := workers[id]
+
+	var busy time.Duration
+	idle := 0
+	done := ctx.Done()
+
+loop:
+	for {
+		select {
+		case <-done:
+			break loop
+		default:
+		}
+
+		task, ok := s.nextTask(id, workers)
+		if !ok {
+			if s.pending.Load() == 0 {
+				// No tasks in heaps and no busy workers to produce them
+				break
+			}
+
+			idle++
+			if idle < spinLimit {
+				runtime.Gosched()
+			} else {
+				time.Sleep(10 * time.Microsecond)
+			}
+			continue
+		}
+		idle = 0
+
+		start := time.Now()
+		pkt.task = task
+		err := s.processTask(iter, ts, buff, pkt)
+		if err != nil {
+			fail(err)
+			break
+		}
+		s.stealingResult(w, pkt)
+		busy += time.Since(start)
+	}
+
+	// Each worker owns its own slot, so no locking is required
+	s.stats.WorkerBusy[id] = busy
+	if s.Logger != nil {
+		s.Logger.Debug("worker stopped", "worker", id, "busy", busy)
+	}
+}
+
+// stealingResult updates the shared state with the results of the processed
+// task and pushes new tasks to the worker heap
+func (s *Solver) stealingResult(w *stealingWorker, pkt *processingPacket) {
+	w.stats.TasksExpanded++
+	if pkt.tail {
+		w.stats.TailCalls++
+	}
+
+	if len(pkt.solution.path) != 0 {
+		s.mu.Lock()
+		s.newSolutionFound(pkt.solution.path, pkt.solution.distance)
+		s.mu.Unlock()
+	}
+
+	newTasks := pkt.newTasks
+	w.stats.TasksCreated += len(newTasks) + pkt.pruned
+	w.stats.PrunedByBound += pkt.pruned
+
+	// The bound might have improved since the task was processed
+	bound := s.bound.Load()
+	count := 0
+	for _, t := range newTasks {
+		if t.Estimate >= bound {
+			w.stats.PrunedByBound++
+			continue
+		}
+		newTasks[count] = t
+		count++
+	}
+
+	if count != 0 {
+		// Children are accounted before their parent is done,
+		// so pending never drops to zero while work remains
+		s.pending.Add(int64(count))
+		w.mu.Lock()
+		w.queue.Insert(newTasks[:count])
+		w.mu.Unlock()
+	}
+
+	pending := s.pending.Add(-1)
+	w.stats.UpdateQueueLen(int(pending))
+
+	expanded := s.expanded.Add(1)
+	if (s.Logger != nil) && (expanded%int64(s.logEvery) == 0) {
+		s.mu.Lock()
+		best := s.bestSolutionDistance
+		s.mu.Unlock()
+
+		searchlog.Progress(s.Logger, int(expanded), int(pending), best, s.start)
+	}
+}
@@ -2,20 +2,22 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"testing"
 
+	"github.com/Spi1y/tsp-solver/gen"
 	solver "github.com/Spi1y/tsp-solver/solver"
 	solver_matrix "github.com/Spi1y/tsp-solver/solver/matrix"
 	solver_tasks "github.com/Spi1y/tsp-solver/solver/tasks"
 	"github.com/Spi1y/tsp-solver/solver3"
 
 	solver2 "github.com/Spi1y/tsp-solver/solver2"
+	solver2_tail "github.com/Spi1y/tsp-solver/solver2/tail"
 	solver2_types "github.com/Spi1y/tsp-solver/solver2/types"
 )
 
 func runBenchmarkSolver1(b *testing.B, bm bmCase, q solver_tasks.QueueType) {
-	matrix17 := baseMatrix17()
-	sizedMatrix := solver_matrix.ConvertToMatrix(matrix17[:bm.size])
+	sizedMatrix := bm.intMatrix()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		s := &solver.Solver{}
@@ -25,11 +27,7 @@ func runBenchmarkSolver1(b *testing.B, bm bmCase, q solver_tasks.QueueType) {
 }
 
 func runBenchmarkSolver2(b *testing.B, bm bmCase, threshold int) {
-	uintMatrix17 := uintBaseMatrix17()
-	sizedMatrix := uintMatrix17[:bm.size]
-	for i := range sizedMatrix {
-		sizedMatrix[i] = sizedMatrix[i][:bm.size]
-	}
+	sizedMatrix := bm.distances()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		s := &solver2.Solver{}
@@ -39,11 +37,7 @@ func runBenchmarkSolver2(b *testing.B, bm bmCase, threshold int) {
 }
 
 func runBenchmarkSolver3(b *testing.B, bm bmCase, threshold int) {
-	uintMatrix17 := uintBaseMatrix17()
-	sizedMatrix := uintMatrix17[:bm.size]
-	for i := range sizedMatrix {
-		sizedMatrix[i] = sizedMatrix[i][:bm.size]
-	}
+	sizedMatrix := bm.distances()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		s := &solver3.Solver{}
@@ -69,6 +63,10 @@ func runBenchmarkCase(b *testing.B, bm bmCase) {
 			runBenchmarkSolver2(b, bm, 3)
 		case 6:
 			runBenchmarkSolver3(b, bm, 3)
+		case 7:
+			runBenchmarkSolver2(b, bm, int(solver2_tail.ThresholdAuto))
+		case 8:
+			runBenchmarkSolver3(b, bm, int(solver2_tail.ThresholdAuto))
 		}
 	})
 }
@@ -80,44 +78,96 @@ func runBenchmarkSet(b *testing.B, size int, solvers []int) {
 }
 
 func BenchmarkSolverSize5(b *testing.B) {
-	runBenchmarkSet(b, 5, []int{1, 2, 3, 4, 5, 6})
+	runBenchmarkSet(b, 5, []int{1, 2, 3, 4, 5, 6, 7, 8})
 }
 
 func BenchmarkSolverSize7(b *testing.B) {
-	runBenchmarkSet(b, 7, []int{1, 2, 3, 4, 5, 6})
+	runBenchmarkSet(b, 7, []int{1, 2, 3, 4, 5, 6, 7, 8})
 }
 
 func BenchmarkSolverSize8(b *testing.B) {
-	runBenchmarkSet(b, 8, []int{1, 2, 3, 4, 5, 6})
+	runBenchmarkSet(b, 8, []int{1, 2, 3, 4, 5, 6, 7, 8})
 }
 
 func BenchmarkSolverSize9(b *testing.B) {
-	runBenchmarkSet(b, 9, []int{1, 2, 3, 4, 5, 6})
+	runBenchmarkSet(b, 9, []int{1, 2, 3, 4, 5, 6, 7, 8})
 }
 
 func BenchmarkSolverSize11(b *testing.B) {
-	runBenchmarkSet(b, 11, []int{1, 2, 3, 5, 6})
+	runBenchmarkSet(b, 11, []int{1, 2, 3, 5, 6, 7, 8})
 }
 
 func BenchmarkSolverSize13(b *testing.B) {
-	runBenchmarkSet(b, 13, []int{2, 3, 5, 6})
+	runBenchmarkSet(b, 13, []int{2, 3, 5, 6, 7, 8})
 }
 
 func BenchmarkSolverSize15(b *testing.B) {
-	runBenchmarkSet(b, 15, []int{2, 3, 5, 6})
+	runBenchmarkSet(b, 15, []int{2, 3, 5, 6, 7, 8})
 }
 
 func BenchmarkSolverSize16(b *testing.B) {
-	runBenchmarkSet(b, 16, []int{3, 5, 6})
+	runBenchmarkSet(b, 16, []int{3, 5, 6, 7, 8})
 }
 
 func BenchmarkSolverSize17(b *testing.B) {
-	runBenchmarkSet(b, 17, []int{3, 5, 6})
+	runBenchmarkSet(b, 17, []int{3, 5, 6, 7, 8})
+}
+
+func BenchmarkSolver3Workers(b *testing.B) {
+	const size = 15
+	uintMatrix17 := uintBaseMatrix17()
+	sizedMatrix := uintMatrix17[:size]
+	for i := range sizedMatrix {
+		sizedMatrix[i] = sizedMatrix[i][:size]
+	}
+
+	for _, workers := range []int{1, 2, 4, 8, 16, 32} {
+		b.Run(fmt.Sprintf("%v	Workers %v", size, workers), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				s := &solver3.Solver{}
+				s.RecursiveThreshold = 3
+				s.Workers = workers
+				s.Solve(sizedMatrix)
+			}
+		})
+	}
+}
+
+// BenchmarkSolverInstance runs solvers on the instance file set by the
+// TSP_BENCH_INSTANCE environment variable (see gen command), or on
+// generated instances of all kinds if it is not set
+func BenchmarkSolverInstance(b *testing.B) {
+	var instances []*gen.Instance
+	if name := os.Getenv("TSP_BENCH_INSTANCE"); name != "" {
+		inst, err := gen.ReadFile(name)
+		if err != nil {
+			b.Fatal(err)
+		}
+		instances = append(instances, inst)
+	} else {
+		for _, kind := range gen.Kinds {
+			inst, err := gen.NewInstance(gen.Options{Kind: kind, Size: 11, Seed: 1})
+			if err != nil {
+				b.Fatal(err)
+			}
+			instances = append(instances, inst)
+		}
+	}
+
+	for _, inst := range instances {
+		for _, solver := range []int{3, 5, 6, 7, 8} {
+			bm := getBMCase(len(inst.Matrix), solver)
+			bm.name = fmt.Sprintf("%v	%v", inst.Name, solverString(solver))
+			bm.matrix = inst.Matrix
+			runBenchmarkCase(b, bm)
+		}
+	}
 }
 
 func getBMCase(size int, solver int) bmCase {
 	return bmCase{
-		name: fmt.Sprintf("%v	%v", size, solverString(solver)),
+		name:   fmt.Sprintf("%v	%v", size, solverString(solver)),
 		size:   size,
 		solver: solver,
 	}
@@ -137,6 +187,10 @@ func solverString(s int) string {
 		return "Solver2	Hybrid"
 	case 6:
 		return "Solver3 (mt)"
+	case 7:
+		return "Solver2	Auto"
+	case 8:
+		return "Solver3	Auto"
 	default:
 		return "Unknown"
 	}
@@ -146,6 +200,31 @@ type bmCase struct {
 	name   string
 	size   int
 	solver int
+	// Distance matrix, a part of the base matrix is used if it is nil
+	matrix [][]solver2_types.Distance
+}
+
+func (bm bmCase) distances() [][]solver2_types.Distance {
+	if bm.matrix != nil {
+		return bm.matrix
+	}
+
+	uintMatrix17 := uintBaseMatrix17()
+	sizedMatrix := uintMatrix17[:bm.size]
+	for i := range sizedMatrix {
+		sizedMatrix[i] = sizedMatrix[i][:bm.size]
+	}
+
+	return sizedMatrix
+}
+
+func (bm bmCase) intMatrix() solver_matrix.Matrix {
+	if bm.matrix != nil {
+		return intMatrix(bm.matrix)
+	}
+
+	matrix17 := baseMatrix17()
+	return solver_matrix.ConvertToMatrix(matrix17[:bm.size])
 }
 
 func baseMatrix17() [][]int {
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/little"
+	solver "github.com/Spi1y/tsp-solver/solver"
+	solver_matrix "github.com/Spi1y/tsp-solver/solver/matrix"
+	solver_tasks "github.com/Spi1y/tsp-solver/solver/tasks"
+	"github.com/Spi1y/tsp-solver/solver3"
+	"github.com/Spi1y/tsp-solver/tour"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Spi1y/tsp-solver/gen"
+	solver2 "github.com/Spi1y/tsp-solver/solver2"
+	solver2_tail "github.com/Spi1y/tsp-solver/solver2/tail"
+	solver2_types "github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Largest instance solved by the brute-force oracle
+const oracleMaxSize = 8
+
+// TestEnginesDifferential checks that all engines agree with the brute-force
+// oracle on random instances of all kinds
+func TestEnginesDifferential(t *testing.T) {
+	for _, kind := range gen.Kinds {
+		for size := 2; size <= oracleMaxSize; size++ {
+			for seed := int64(0); seed < 3; seed++ {
+				t.Run(fmt.Sprintf("%s/%d/%d", kind, size, seed), func(t *testing.T) {
+					checkEngines(t, gen.Options{Kind: kind, Size: size, Seed: seed, MaxDistance: 1_000})
+				})
+			}
+		}
+	}
+}
+
+func FuzzEnginesDifferential(f *testing.F) {
+	f.Add(int64(1), uint8(gen.Uniform), uint8(5))
+	f.Add(int64(2), uint8(gen.Clustered), uint8(7))
+	f.Add(int64(3), uint8(gen.Ties), uint8(8))
+
+	f.Fuzz(func(t *testing.T, seed int64, kind uint8, size uint8) {
+		checkEngines(t, gen.Options{
+			Kind: gen.Kinds[int(kind)%len(gen.Kinds)],
+			Size: 2 + int(size)%(oracleMaxSize-1),
+			Seed: seed,
+		})
+	})
+}
+
+func checkEngines(t *testing.T, o gen.Options) {
+	m, err := gen.Generate(o)
+	if !assert.NoError(t, err) {
+		return
+	}
+	want := bruteForce(m)
+
+	for _, q := range []solver_tasks.QueueType{solver_tasks.QueueLinkedList, solver_tasks.QueueHeap} {
+		s := &solver.Solver{DistanceMatrix: intMatrix(m)}
+		path, dist, err := s.Solve(q)
+		assert.NoError(t, err)
+
+		// solver returns open paths of int nodes
+		tr, err := tour.FromInts(path)
+		assert.NoError(t, err)
+		assertTour(t, m, want, tr.Path, solver2_types.Distance(dist), "solver", q)
+	}
+
+	for _, threshold := range []solver2_types.Index{0, 3, solver2_tail.ThresholdAuto} {
+		s := &solver2.Solver{RecursiveThreshold: threshold}
+		path, dist, _, err := s.Solve(m)
+		assert.NoError(t, err)
+		assertTour(t, m, want, path, dist, "solver2", threshold)
+	}
+
+	for _, deterministic := range []bool{false, true} {
+		s := &solver3.Solver{Workers: 2, RecursiveThreshold: 3, Deterministic: deterministic}
+		path, dist, _, err := s.Solve(m)
+		assert.NoError(t, err)
+		assertTour(t, m, want, path, dist, "solver3", deterministic)
+	}
+
+	s := &little.Solver{}
+	path, dist, _, err := s.Solve(m)
+	assert.NoError(t, err)
+	assertTour(t, m, want, path, dist, "little", nil)
+}
+
+// assertTour checks that the path is a valid tour of the optimal distance
+func assertTour(t *testing.T, m [][]solver2_types.Distance, want solver2_types.Distance, path []solver2_types.Index, dist solver2_types.Distance, engine string, config interface{}) {
+	tr, err := tour.New(path)
+	if assert.NoError(t, err, "%s %v", engine, config) {
+		assert.NoError(t, tr.Validate(m), "%s %v", engine, config)
+		assert.Equal(t, dist, tr.Distance(m), "%s %v", engine, config)
+	}
+	assert.Equal(t, want, dist, "%s %v", engine, config)
+}
+
+// bruteForce calculates the optimal distance by enumerating all tours
+func bruteForce(m [][]solver2_types.Distance) solver2_types.Distance {
+	size := len(m)
+	visited := make([]bool, size)
+	visited[0] = true
+
+	var best solver2_types.Distance
+	found := false
+
+	var visit func(curr int, depth int, dist solver2_types.Distance)
+	visit = func(curr int, depth int, dist solver2_types.Distance) {
+		if depth == size {
+			dist += m[curr][0]
+			if !found || (dist < best) {
+				best, found = dist, true
+			}
+			return
+		}
+
+		for next := 1; next < size; next++ {
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			visit(next, depth+1, dist+m[curr][next])
+			visited[next] = false
+		}
+	}
+	visit(0, 1, 0)
+
+	return best
+}
+
+func intMatrix(m [][]solver2_types.Distance) solver_matrix.Matrix {
+	slice := make([][]int, len(m))
+	for i := range m {
+		slice[i] = make([]int, len(m))
+		for j := range m[i] {
+			slice[i][j] = int(m[i][j])
+		}
+		slice[i][i] = -1
+	}
+
+	return solver_matrix.ConvertToMatrix(slice)
+}
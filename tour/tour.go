@@ -0,0 +1,199 @@
+// Package tour implements a solver independent representation of the solution
+package tour
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+)
+
+// Tour is a closed path through all nodes. Path always finishes with
+// the starting node, so a tour through n nodes has n+1 elements.
+type Tour struct {
+	Path []types.Index
+	// Optional identifiers and names of the nodes, indexed by the node
+	IDs    []string
+	Labels []string
+}
+
+// New creates a tour from a solver path. The path may be open (as returned
+// by solver) or closed (as returned by solver2 and solver3).
+func New(path []types.Index) (Tour, error) {
+	if len(path) == 0 {
+		return Tour{}, errors.New("Path is empty")
+	}
+
+	closed := make([]types.Index, len(path), len(path)+1)
+	copy(closed, path)
+	if (len(path) == 1) || (path[0] != path[len(path)-1]) {
+		closed = append(closed, path[0])
+	}
+
+	return Tour{Path: closed}, nil
+}
+
+// FromInts creates a tour from a path of solver
+func FromInts(path []int) (Tour, error) {
+	converted := make([]types.Index, len(path))
+	for i, node := range path {
+		if (node < 0) || (node > math.MaxUint8) {
+			return Tour{}, fmt.Errorf("Node %d is out of range", node)
+		}
+		converted[i] = types.Index(node)
+	}
+
+	return New(converted)
+}
+
+// Len returns the number of nodes in the tour
+func (t Tour) Len() int {
+	if len(t.Path) == 0 {
+		return 0
+	}
+
+	return len(t.Path) - 1
+}
+
+// Nodes returns the nodes in visiting order without the closing one
+func (t Tour) Nodes() []types.Index {
+	return t.Path[:t.Len()]
+}
+
+// Rotate returns the same tour starting and finishing with the given node
+func (t Tour) Rotate(start types.Index) (Tour, error) {
+	nodes := t.Nodes()
+	for i, node := range nodes {
+		if node != start {
+			continue
+		}
+
+		path := make([]types.Index, 0, len(t.Path))
+		path = append(path, nodes[i:]...)
+		path = append(path, nodes[:i]...)
+		path = append(path, start)
+
+		return Tour{Path: path, IDs: t.IDs, Labels: t.Labels}, nil
+	}
+
+	return Tour{}, fmt.Errorf("Node %d is not in the tour", start)
+}
+
+// Reverse returns the tour traveled in the opposite direction
+// from the same starting node
+func (t Tour) Reverse() Tour {
+	path := make([]types.Index, len(t.Path))
+	for i, node := range t.Path {
+		path[len(t.Path)-1-i] = node
+	}
+
+	return Tour{Path: path, IDs: t.IDs, Labels: t.Labels}
+}
+
+// Validate checks that the tour visits every node of the matrix exactly once
+// and returns to the start
+func (t Tour) Validate(m [][]types.Distance) error {
+	size := len(m)
+	if size == 0 {
+		return errors.New("Distance matrix is empty")
+	}
+	if len(t.Path) == 0 {
+		return errors.New("Tour is empty")
+	}
+	if t.Len() != size {
+		return fmt.Errorf("Tour has %d nodes, matrix has %d", t.Len(), size)
+	}
+
+	if t.Path[0] != t.Path[len(t.Path)-1] {
+		return errors.New("Tour is not closed")
+	}
+
+	visited := make([]bool, size)
+	for _, node := range t.Nodes() {
+		if int(node) >= size {
+			return fmt.Errorf("Node %d is out of range", node)
+		}
+		if visited[node] {
+			return fmt.Errorf("Node %d is visited twice", node)
+		}
+		visited[node] = true
+	}
+
+	return nil
+}
+
+// Legs returns distances of the tour legs, the i-th leg leads
+// from Path[i] to Path[i+1]
+func (t Tour) Legs(m [][]types.Distance) []types.Distance {
+	if len(t.Path) < 2 {
+		return []types.Distance{}
+	}
+
+	legs := make([]types.Distance, len(t.Path)-1)
+	for i := range legs {
+		legs[i] = m[t.Path[i]][t.Path[i+1]]
+	}
+
+	return legs
+}
+
+// Cumulative returns the distance traveled by the end of each leg
+func (t Tour) Cumulative(m [][]types.Distance) []types.Distance {
+	legs := t.Legs(m)
+
+	var total types.Distance
+	for i := range legs {
+		total += legs[i]
+		legs[i] = total
+	}
+
+	return legs
+}
+
+// Distance returns the total distance of the tour
+func (t Tour) Distance(m [][]types.Distance) types.Distance {
+	var total types.Distance
+	for _, leg := range t.Legs(m) {
+		total += leg
+	}
+
+	return total
+}
+
+// ID returns the identifier of the node at the given position of the path.
+// The node index is used if there is no identifier.
+func (t Tour) ID(pos int) string {
+	node := t.Path[pos]
+	if (int(node) < len(t.IDs)) && (t.IDs[node] != "") {
+		return t.IDs[node]
+	}
+
+	return strconv.Itoa(int(node))
+}
+
+// Label returns the name of the node at the given position of the path.
+// The identifier is used if there is no name.
+func (t Tour) Label(pos int) string {
+	node := t.Path[pos]
+	if (int(node) < len(t.Labels)) && (t.Labels[node] != "") {
+		return t.Labels[node]
+	}
+
+	return t.ID(pos)
+}
+
+// String implements the Stringer interface
+func (t Tour) String() string {
+	var b strings.Builder
+	for i := range t.Path {
+		if i != 0 {
+			b.WriteString(" -> ")
+		}
+		b.WriteString(t.Label(i))
+	}
+
+	return b.String()
+}
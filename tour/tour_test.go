@@ -0,0 +1,128 @@
+package tour
+
+import (
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    []types.Index
+		want    []types.Index
+		wantErr bool
+	}{
+		{"Empty", []types.Index{}, nil, true},
+		{"Single node", []types.Index{0}, []types.Index{0, 0}, false},
+		{"Open", []types.Index{0, 2, 1}, []types.Index{0, 2, 1, 0}, false},
+		{"Closed", []types.Index{0, 2, 1, 0}, []types.Index{0, 2, 1, 0}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.Path)
+		})
+	}
+}
+
+func TestFromInts(t *testing.T) {
+	got, err := FromInts([]int{0, 2, 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Index{0, 2, 1, 0}, got.Path)
+
+	_, err = FromInts([]int{0, -1})
+	assert.Error(t, err)
+	_, err = FromInts([]int{0, 256})
+	assert.Error(t, err)
+}
+
+func TestTour_RotateReverse(t *testing.T) {
+	tour, _ := New([]types.Index{0, 2, 3, 1})
+
+	rotated, err := tour.Rotate(3)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Index{3, 1, 0, 2, 3}, rotated.Path)
+
+	_, err = tour.Rotate(4)
+	assert.Error(t, err)
+
+	assert.Equal(t, []types.Index{0, 1, 3, 2, 0}, tour.Reverse().Path)
+	// Original tour is not changed
+	assert.Equal(t, []types.Index{0, 2, 3, 1, 0}, tour.Path)
+}
+
+func TestTour_Validate(t *testing.T) {
+	m := testMatrix()
+	tests := []struct {
+		name    string
+		tour    Tour
+		wantErr bool
+	}{
+		{"Valid", Tour{Path: []types.Index{0, 2, 1, 3, 0}}, false},
+		{"Rotated", Tour{Path: []types.Index{2, 1, 3, 0, 2}}, false},
+		{"Too short", Tour{Path: []types.Index{0, 2, 1, 0}}, true},
+		{"Not closed", Tour{Path: []types.Index{0, 2, 1, 3, 2}}, true},
+		{"Duplicate", Tour{Path: []types.Index{0, 2, 2, 3, 0}}, true},
+		{"Out of range", Tour{Path: []types.Index{0, 2, 1, 4, 0}}, true},
+		{"Empty", Tour{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tour.Validate(m)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	assert.Error(t, Tour{}.Validate(nil))
+	assert.Error(t, Tour{Path: []types.Index{0}}.Validate(nil))
+}
+
+func TestTour_Distances(t *testing.T) {
+	m := testMatrix()
+	tour, _ := New([]types.Index{0, 2, 1, 3})
+
+	assert.Equal(t, []types.Distance{2, 6, 5, 10}, tour.Legs(m))
+	assert.Equal(t, []types.Distance{2, 8, 13, 23}, tour.Cumulative(m))
+	assert.Equal(t, types.Distance(23), tour.Distance(m))
+
+	// Asymmetric matrix gives another distance in reverse
+	assert.Equal(t, types.Distance(29), tour.Reverse().Distance(m))
+	assert.Equal(t, types.Distance(23), mustRotate(t, tour, 1).Distance(m))
+}
+
+func TestTour_String(t *testing.T) {
+	tour, _ := New([]types.Index{0, 2, 1})
+	assert.Equal(t, "0 -> 2 -> 1 -> 0", tour.String())
+
+	tour.IDs = []string{"depot", "", "b"}
+	tour.Labels = []string{"", "", "Store B"}
+	assert.Equal(t, "depot -> Store B -> 1 -> depot", tour.String())
+	assert.Equal(t, "b", tour.ID(1))
+}
+
+func mustRotate(t *testing.T, tour Tour, start types.Index) Tour {
+	rotated, err := tour.Rotate(start)
+	assert.NoError(t, err)
+	return rotated
+}
+
+func testMatrix() [][]types.Distance {
+	return [][]types.Distance{
+		{0, 1, 2, 3},
+		{4, 0, 7, 5},
+		{8, 6, 0, 9},
+		{10, 11, 12, 0},
+	}
+}
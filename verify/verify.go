@@ -0,0 +1,190 @@
+// Package verify independently checks solutions returned by solvers
+package verify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/Spi1y/tsp-solver/solver2/tail"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/Spi1y/tsp-solver/tour"
+)
+
+// Claim is a solution reported by a solver
+type Claim struct {
+	Path     []types.Index
+	Distance types.Distance
+	// Lower bound of the optimal distance reported by the solver. If it equals
+	// to the distance, the solver claims that the solution is optimal
+	LowerBound types.Distance
+	// Optional search log, distances of the best solution improvements
+	Incumbents []types.Distance
+}
+
+// Report is a result of the successful verification
+type Report struct {
+	// Distance recalculated from the matrix
+	Distance types.Distance
+	// Solver claims the solution is optimal
+	Claimed bool
+	// Optimality is proven independently, either by the exact optimum or
+	// by the root bound. Claims on matrices too large for the exact solver
+	// usually stay not proven.
+	Optimal bool
+	// Relative gap between the distance and the lower bound
+	Gap float64
+	// Lower bound of the root matrix reduction, calculated independently
+	RootBound types.Distance
+	// Optimal distance calculated by the independent exact solver.
+	// Only available if Exact is set, on matrices up to tail.MaxSize+1 nodes
+	Optimum types.Distance
+	Exact   bool
+}
+
+// Check verifies that the path is a Hamiltonian cycle with a claimed
+// distance and that the claimed lower bound is consistent with the search log,
+// the root bound and (on small matrices) with the exact optimum.
+func Check(m [][]types.Distance, c Claim) (Report, error) {
+	r := Report{}
+
+	for i := range m {
+		if len(m[i]) != len(m) {
+			return r, errors.New("Distance matrix is not square")
+		}
+	}
+
+	t, err := tour.New(c.Path)
+	if err != nil {
+		return r, err
+	}
+	err = t.Validate(m)
+	if err != nil {
+		return r, err
+	}
+	if t.Path[0] != 0 {
+		return r, fmt.Errorf("Tour starts with node %d instead of 0", t.Path[0])
+	}
+
+	r.Distance = t.Distance(m)
+	if r.Distance != c.Distance {
+		return r, fmt.Errorf("Claimed distance %d does not match the tour distance %d", c.Distance, r.Distance)
+	}
+
+	if c.LowerBound > c.Distance {
+		return r, fmt.Errorf("Lower bound %d is above the distance %d", c.LowerBound, c.Distance)
+	}
+	r.Claimed = c.LowerBound == c.Distance
+	if c.Distance != 0 {
+		r.Gap = float64(c.Distance-c.LowerBound) / float64(c.Distance)
+	}
+
+	for i := range c.Incumbents {
+		if (i > 0) && (c.Incumbents[i] >= c.Incumbents[i-1]) {
+			return r, fmt.Errorf("Incumbent %d does not improve the previous one", i)
+		}
+	}
+	if (len(c.Incumbents) != 0) && (c.Incumbents[len(c.Incumbents)-1] != c.Distance) {
+		return r, fmt.Errorf("Last incumbent %d does not match the distance %d", c.Incumbents[len(c.Incumbents)-1], c.Distance)
+	}
+
+	r.RootBound = RootBound(m)
+	if r.RootBound > c.Distance {
+		return r, fmt.Errorf("Distance %d is below the root bound %d", c.Distance, r.RootBound)
+	}
+	r.Optimal = r.RootBound == c.Distance
+
+	if len(m)-1 <= tail.MaxSize {
+		r.Optimum = Optimum(m)
+		r.Exact = true
+
+		if c.LowerBound > r.Optimum {
+			return r, fmt.Errorf("Lower bound %d is above the optimum %d", c.LowerBound, r.Optimum)
+		}
+		r.Optimal = r.Distance == r.Optimum
+	}
+
+	return r, nil
+}
+
+// RootBound calculates the lower bound of any tour by the row and column
+// reduction of the matrix. Diagonal values are ignored.
+func RootBound(m [][]types.Distance) types.Distance {
+	size := len(m)
+	if size < 2 {
+		return 0
+	}
+
+	rowMin := make([]types.Distance, size)
+	var bound types.Distance
+	for i := range m {
+		rowMin[i] = math.MaxUint32
+		for j, val := range m[i] {
+			if (i != j) && (val < rowMin[i]) {
+				rowMin[i] = val
+			}
+		}
+		bound += rowMin[i]
+	}
+
+	for j := 0; j < size; j++ {
+		var colMin types.Distance = math.MaxUint32
+		for i := range m {
+			if (i != j) && (m[i][j]-rowMin[i] < colMin) {
+				colMin = m[i][j] - rowMin[i]
+			}
+		}
+		bound += colMin
+	}
+
+	return bound
+}
+
+// Optimum calculates the optimal distance with the exact DP solver.
+// Matrix must have at most tail.MaxSize+1 nodes.
+func Optimum(m [][]types.Distance) types.Distance {
+	nodes := make([]types.Index, len(m)-1)
+	for i := range nodes {
+		nodes[i] = types.Index(i + 1)
+	}
+
+	ts := &tail.Solver{}
+	_, distance := ts.Solve(m, 0, nodes)
+
+	return distance
+}
+
+// Certificate is a JSON document with a matrix and a claimed solution,
+// consumed by the verify command
+type Certificate struct {
+	Matrix     [][]types.Distance `json:"matrix"`
+	Path       []int              `json:"path"`
+	Distance   types.Distance     `json:"distance"`
+	LowerBound types.Distance     `json:"lowerBound"`
+	Incumbents []types.Distance   `json:"incumbents,omitempty"`
+}
+
+// ReadCertificate decodes a certificate from the reader
+func ReadCertificate(r io.Reader) (Certificate, error) {
+	c := Certificate{}
+	err := json.NewDecoder(r).Decode(&c)
+
+	return c, err
+}
+
+// Check verifies the certificate claim
+func (c Certificate) Check() (Report, error) {
+	t, err := tour.FromInts(c.Path)
+	if err != nil {
+		return Report{}, err
+	}
+
+	return Check(c.Matrix, Claim{
+		Path:       t.Path,
+		Distance:   c.Distance,
+		LowerBound: c.LowerBound,
+		Incumbents: c.Incumbents,
+	})
+}
@@ -0,0 +1,238 @@
+package verify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Spi1y/tsp-solver/gen"
+	"github.com/Spi1y/tsp-solver/solver"
+	"github.com/Spi1y/tsp-solver/solver/matrix"
+	"github.com/Spi1y/tsp-solver/solver/tasks"
+	"github.com/Spi1y/tsp-solver/solver2"
+	"github.com/Spi1y/tsp-solver/solver2/stats"
+	"github.com/Spi1y/tsp-solver/solver2/tail"
+	"github.com/Spi1y/tsp-solver/solver2/types"
+	"github.com/Spi1y/tsp-solver/solver3"
+	"github.com/Spi1y/tsp-solver/tour"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	m := testMatrix()
+	tests := []struct {
+		name    string
+		claim   Claim
+		optimal bool
+		wantErr bool
+	}{
+		{
+			"Optimal",
+			Claim{Path: []types.Index{0, 1, 3, 2, 0}, Distance: 4, LowerBound: 4, Incumbents: []types.Distance{12, 4}},
+			true,
+			false,
+		},
+		{
+			"Interrupted",
+			Claim{Path: []types.Index{0, 2, 1, 3, 0}, Distance: 28, LowerBound: 4},
+			false,
+			false,
+		},
+		{
+			"Open path",
+			Claim{Path: []types.Index{0, 1, 3, 2}, Distance: 4, LowerBound: 4},
+			true,
+			false,
+		},
+		{
+			"Not Hamiltonian",
+			Claim{Path: []types.Index{0, 1, 3, 1, 0}, Distance: 4, LowerBound: 4},
+			false,
+			true,
+		},
+		{
+			"Not from root",
+			Claim{Path: []types.Index{1, 3, 2, 0, 1}, Distance: 4, LowerBound: 4},
+			false,
+			true,
+		},
+		{
+			"Wrong distance",
+			Claim{Path: []types.Index{0, 1, 3, 2, 0}, Distance: 5, LowerBound: 5},
+			false,
+			true,
+		},
+		{
+			"Bound above distance",
+			Claim{Path: []types.Index{0, 1, 3, 2, 0}, Distance: 4, LowerBound: 5},
+			false,
+			true,
+		},
+		{
+			"False optimality",
+			Claim{Path: []types.Index{0, 2, 1, 3, 0}, Distance: 28, LowerBound: 28},
+			false,
+			true,
+		},
+		{
+			"Incumbents not decreasing",
+			Claim{Path: []types.Index{0, 1, 3, 2, 0}, Distance: 4, LowerBound: 4, Incumbents: []types.Distance{4, 4}},
+			false,
+			true,
+		},
+		{
+			"Last incumbent mismatch",
+			Claim{Path: []types.Index{0, 1, 3, 2, 0}, Distance: 4, LowerBound: 4, Incumbents: []types.Distance{12}},
+			false,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Check(m, tt.claim)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.optimal, r.Optimal)
+			assert.Equal(t, tt.optimal, r.Claimed)
+			assert.Equal(t, tt.claim.Distance, r.Distance)
+			assert.True(t, r.Exact)
+			assert.Equal(t, types.Distance(4), r.Optimum)
+			assert.LessOrEqual(t, r.RootBound, r.Optimum)
+		})
+	}
+
+	_, err := Check([][]types.Distance{{0, 1}, {1}}, Claim{Path: []types.Index{0, 1, 0}})
+	assert.Error(t, err)
+}
+
+func TestCheckNotProven(t *testing.T) {
+	size := tail.MaxSize + 3
+	m, err := gen.Generate(gen.Options{Kind: gen.Uniform, Size: size, Seed: 1})
+	assert.NoError(t, err)
+
+	path := make([]types.Index, size+1)
+	for i := 0; i < size; i++ {
+		path[i] = types.Index(i)
+	}
+	distance := tour.Tour{Path: path}.Distance(m)
+
+	// Optimality claim is consistent, but can't be proven without the exact optimum
+	r, err := Check(m, Claim{Path: path, Distance: distance, LowerBound: distance})
+	assert.NoError(t, err)
+	assert.True(t, r.Claimed)
+	assert.False(t, r.Exact)
+	assert.False(t, r.Optimal)
+}
+
+func TestRootBound(t *testing.T) {
+	assert.Equal(t, types.Distance(0), RootBound([][]types.Distance{{5}}))
+	assert.Equal(t, types.Distance(4), RootBound(testMatrix()))
+	assert.Equal(t, types.Distance(11), RootBound([][]types.Distance{
+		{0, 3, 5},
+		{4, 0, 6},
+		{2, 7, 0},
+	}))
+}
+
+func TestCertificate(t *testing.T) {
+	in := `{"matrix": [[0, 1, 9], [9, 0, 1], [1, 9, 0]], "path": [0, 1, 2], "distance": 3, "lowerBound": 3}`
+	c, err := ReadCertificate(strings.NewReader(in))
+	assert.NoError(t, err)
+
+	r, err := c.Check()
+	assert.NoError(t, err)
+	assert.True(t, r.Optimal)
+
+	c.Path = []int{0, 2, 1}
+	_, err = c.Check()
+	assert.Error(t, err)
+
+	c.Path = []int{0, -1}
+	_, err = c.Check()
+	assert.Error(t, err)
+}
+
+// TestEngines cross-checks solutions of all engines
+func TestEngines(t *testing.T) {
+	for _, m := range engineTestCases() {
+		// solver
+		s1 := &solver.Solver{DistanceMatrix: toMatrix(m)}
+		path1, dist1, err := s1.Solve(tasks.QueueLinkedList)
+		assert.NoError(t, err)
+		t1, err := tour.FromInts(path1)
+		assert.NoError(t, err)
+		assertOptimal(t, m, Claim{Path: t1.Path, Distance: types.Distance(dist1), LowerBound: types.Distance(dist1)})
+
+		// solver2 with several thresholds
+		for _, threshold := range []types.Index{0, 3, tail.ThresholdAuto} {
+			s2 := &solver2.Solver{RecursiveThreshold: threshold}
+			path2, dist2, st, err := s2.Solve(m)
+			assert.NoError(t, err)
+			assertOptimal(t, m, claimFromStats(path2, dist2, st.LowerBound, st.Incumbents))
+		}
+
+		// solver3
+		s3 := &solver3.Solver{Workers: 2}
+		path3, dist3, st, err := s3.Solve(m)
+		assert.NoError(t, err)
+		assertOptimal(t, m, claimFromStats(path3, dist3, st.LowerBound, st.Incumbents))
+	}
+}
+
+func assertOptimal(t *testing.T, m [][]types.Distance, c Claim) {
+	r, err := Check(m, c)
+	if assert.NoError(t, err) {
+		assert.True(t, r.Optimal)
+		assert.Equal(t, r.Optimum, r.Distance)
+	}
+}
+
+func claimFromStats(path []types.Index, dist types.Distance, bound types.Distance, incumbents []stats.Incumbent) Claim {
+	c := Claim{Path: path, Distance: dist, LowerBound: bound}
+	for _, inc := range incumbents {
+		c.Incumbents = append(c.Incumbents, inc.Distance)
+	}
+
+	return c
+}
+
+func toMatrix(m [][]types.Distance) matrix.Matrix {
+	slice := make([][]int, len(m))
+	for i := range m {
+		slice[i] = make([]int, len(m))
+		for j := range m[i] {
+			slice[i][j] = int(m[i][j])
+		}
+		slice[i][i] = -1
+	}
+
+	return matrix.ConvertToMatrix(slice)
+}
+
+func engineTestCases() [][][]types.Distance {
+	return [][][]types.Distance{
+		testMatrix(),
+		{
+			{0, 15147, 21742, 12730, 18594, 6147, 6955, 10000},
+			{17465, 0, 30524, 22534, 27376, 20763, 15326, 21214},
+			{23594, 43627, 0, 16165, 9604, 21957, 18560, 21180},
+			{11103, 22595, 16255, 0, 10210, 5909, 7880, 3274},
+			{19133, 27796, 9754, 10054, 0, 12856, 14099, 10486},
+			{6155, 21069, 23218, 7694, 14520, 0, 5419, 4964},
+			{5736, 14952, 18081, 8492, 14933, 6300, 0, 7172},
+			{10801, 21605, 17131, 4504, 11197, 3615, 6890, 0},
+		},
+	}
+}
+
+func testMatrix() [][]types.Distance {
+	return [][]types.Distance{
+		{0, 1, 9, 9},
+		{9, 0, 9, 1},
+		{1, 9, 0, 9},
+		{9, 9, 1, 0},
+	}
+}